@@ -115,6 +115,26 @@ messageLoop:
 			}
 			c.SendDecrypted(data)
 
+		case *agent.MsgSign:
+			key, ok := identities[m.KeyID]
+			if !ok {
+				txt := fmt.Sprintf("Unknown key: %s", m.KeyID)
+				log.Printf("%s\n", txt)
+				c.SendError(txt)
+				continue
+			}
+			signer, ok := key.(identity.Signer)
+			if !ok {
+				c.SendError(fmt.Sprintf("Key %s can't sign", m.KeyID))
+				continue
+			}
+			signature, err := signer.Sign(m.Data)
+			if err != nil {
+				c.SendError(err.Error())
+				continue
+			}
+			c.SendSignature(signature)
+
 		default:
 			txt := fmt.Sprintf("Unsupported client message '%s'", msg.Type())
 			log.Printf("%s\n", txt)
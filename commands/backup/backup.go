@@ -9,85 +9,331 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"os"
+	"os/user"
+	"strings"
 
 	"github.com/markkurossi/backup/lib/agent"
+	"github.com/markkurossi/backup/lib/config"
+	"github.com/markkurossi/backup/lib/crypto/identity"
 	"github.com/markkurossi/backup/lib/crypto/zone"
+	"github.com/markkurossi/backup/lib/objtree"
 	"github.com/markkurossi/backup/lib/persistence"
+	"github.com/markkurossi/backup/lib/util"
 )
 
 var commands = map[string]func(){
-	"add-key": cmdAddKey,
-	"init":    cmdInit,
-	"keygen":  cmdKeygen,
-	"ls":      cmdLs,
-	"update":  cmdUpdate,
-	"zone":    cmdZone,
+	"add-key":    cmdAddKey,
+	"browse":     cmdBrowse,
+	"check":      cmdCheck,
+	"config":     cmdConfig,
+	"du":         cmdDu,
+	"export":     cmdExport,
+	"find":       cmdFind,
+	"forget":     cmdForget,
+	"import-tar": cmdImportTar,
+	"index":      cmdIndex,
+	"init":       cmdInit,
+	"key":        cmdKey,
+	"keygen":     cmdKeygen,
+	"ls":         cmdLs,
+	"recover":    cmdRecover,
+	"stats":      cmdStats,
+	"update":     cmdUpdate,
+	"zone":       cmdZone,
+}
+
+// version defines the tool version that is recorded in snapshots.
+const version = "0.2.0"
+
+// stringList implements a flag.Value that collects repeated flag
+// values.
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+// Set implements flag.Value.Set.
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
 }
 
 var address = flag.String("a", "", "Agent UNIX-domain socket address.")
+var repository = flag.String("r", "", "Repository name or URL.")
+var zoneName = flag.String("z", "", "Zone name.")
 var verbose = flag.Bool("v", false, "Enable verbose output.")
 
 var client *agent.Client
+var agents []*agent.Client
+
+var settings *config.Settings
+var userConfig *config.Config
+var repoConfig *config.Config
+
+// loadSettings resolves the effective settings from the
+// configuration files, environment, and command line flags. The
+// invalid configuration values are reported after both configuration
+// files are loaded so that the config command can fix them.
+func loadSettings() error {
+	settings = config.NewSettings()
+
+	u, err := user.Current()
+	if err != nil {
+		return err
+	}
+	userConfig, err = config.Load(config.UserConfigPath(u))
+	if err != nil {
+		return err
+	}
+	applyErr := settings.Apply(userConfig)
+	applyOverrides()
+	settings.Resolve(userConfig)
+
+	path := config.RepositoryConfigPath(settings.URL)
+	if len(path) > 0 {
+		repoConfig, err = config.Load(path)
+		if err != nil {
+			return err
+		}
+		if err := settings.Apply(repoConfig); err != nil && applyErr == nil {
+			applyErr = err
+		}
+		applyOverrides()
+	}
+	return applyErr
+}
+
+func applyOverrides() {
+	settings.ApplyEnv()
+	if len(*address) > 0 {
+		settings.AgentSocket = *address
+	}
+	if len(*repository) > 0 {
+		settings.Repository = *repository
+	}
+	if len(*zoneName) > 0 {
+		settings.Zone = *zoneName
+	}
+}
 
+// connectAgent connects to the key agents. The client is connected
+// to the first agent socket and agents to all agent sockets. The
+// function exits on errors.
 func connectAgent() {
-	var path string
-	var err error
-
-	if len(*address) == 0 {
-		var ok bool
-		path, ok = os.LookupEnv(sockEnv)
-		if !ok {
-			fmt.Printf("Agent socket environment variable %s not set\n",
-				sockEnv)
+	paths := settings.AgentSockets()
+	if len(paths) == 0 {
+		fmt.Printf("Agent socket environment variable %s not set\n",
+			config.EnvAgentSocket)
+		os.Exit(1)
+	}
+
+	agents = nil
+	for _, path := range paths {
+		c, err := agent.NewClient(path)
+		if err != nil {
+			fmt.Printf("Failed to connect to agent '%s': %s\n", path, err)
 			os.Exit(1)
 		}
-	} else {
-		path = *address
+		agents = append(agents, c)
 	}
+	client = agents[0]
+}
 
-	client, err = agent.NewClient(path)
-	if err != nil {
-		fmt.Printf("Failed to connect to agent '%s': %s\n", path, err)
+// dialAgents connects to the key agents like connectAgent but it
+// skips the agent sockets that are not available. The client is nil
+// if none of the agents is available.
+func dialAgents() {
+	agents = nil
+	client = nil
+	for _, path := range settings.AgentSockets() {
+		c, err := agent.NewClient(path)
+		if err != nil {
+			continue
+		}
+		agents = append(agents, c)
+	}
+	if len(agents) > 0 {
+		client = agents[0]
+	}
+}
+
+// listAgentKeys lists the identity keys of all connected agents.
+func listAgentKeys() ([]identity.PrivateKey, error) {
+	var result []identity.PrivateKey
+	for _, c := range agents {
+		keys, err := c.ListKeys()
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, keys...)
+	}
+	return result, nil
+}
+
+func openPersistence() (persistence.Accessor, error) {
+	path, ok := config.FilesystemPath(settings.URL)
+	if ok {
+		return persistence.OpenFilesystem(path)
+	}
+	if strings.HasPrefix(settings.URL, "http://") ||
+		strings.HasPrefix(settings.URL, "https://") {
+		h, err := persistence.NewHTTP(settings.URL)
+		if err != nil {
+			return nil, err
+		}
+		h.Token, err = settings.ReadCredentials()
+		if err != nil {
+			return nil, err
+		}
+		return h, nil
+	}
+	return nil, fmt.Errorf("unsupported repository URL '%s'", settings.URL)
+}
+
+// openZone opens the zone. The zone must be opened with a full
+// identity. The function exits on errors.
+func openZone() (*zone.Zone, string) {
+	z, wd := openZoneWith(zone.Open)
+	requireFullIdentity(z)
+	return z, wd
+}
+
+// openZoneForUpdate opens the zone for adding new snapshots. Unlike
+// openZone, the function accepts the write-only identities of the
+// zone. The function exits on errors.
+func openZoneForUpdate() (*zone.Zone, string) {
+	return openZoneWith(zone.Open)
+}
+
+// requireFullIdentity exits if the zone was opened with a write-only
+// identity.
+func requireFullIdentity(z *zone.Zone) {
+	if z.WriteOnly() {
+		fmt.Printf("Zone '%s' opened with a write-only identity, "+
+			"operation requires a full identity\n", z.Name)
 		os.Exit(1)
 	}
 }
 
-func openZone(name string) (*zone.Zone, string) {
-	connectAgent()
+// openZoneWith opens the zone with the open function. The key agents
+// are optional: if none of the agent keys can open the zone, the
+// function prompts for the zone recovery key. The function exits on
+// errors.
+func openZoneWith(open func(persistence.Accessor, string,
+	[]identity.PrivateKey) (*zone.Zone, error)) (*zone.Zone, string) {
 
-	keys, err := client.ListKeys()
+	dialAgents()
+
+	keys, err := listAgentKeys()
 	if err != nil {
 		fmt.Printf("Failed to get identity keys: %s\n", err)
 		os.Exit(1)
 	}
-	if len(keys) == 0 {
-		fmt.Printf("No identity keys defined\n")
-		os.Exit(1)
-	}
 
 	wd, err := os.Getwd()
 	if err != nil {
 		fmt.Printf("Failed to get current working directory: %s\n", err)
 		os.Exit(1)
 	}
-	root, err := persistence.OpenFilesystem(fmt.Sprintf("%s/.backup", wd))
+	root, err := openPersistence()
 	if err != nil {
 		fmt.Printf("%s\n", err)
 		os.Exit(1)
 	}
 
-	z, err := zone.Open(root, "default", keys)
+	z, err := open(root, settings.Zone, keys)
+	if errors.Is(err, zone.ErrNoKey) {
+		// Try the zone recovery key.
+		ok, _ := zone.HasRecoveryKey(root, settings.Zone)
+		if ok {
+			fmt.Printf("%s\n", err)
+			key := readRecoveryKey()
+			z, err = open(root, settings.Zone, append(keys, key))
+		} else if len(settings.AgentSockets()) == 0 {
+			err = fmt.Errorf("Agent socket environment variable %s not set",
+				config.EnvAgentSocket)
+		} else if len(keys) == 0 {
+			err = errors.New("No identity keys defined")
+		}
+	}
 	if err != nil {
 		fmt.Printf("%s\n", err)
+		if errors.Is(err, zone.ErrRootPointer) {
+			fmt.Printf("Use 'backup recover' to recover the root pointer\n")
+		}
 		os.Exit(1)
 	}
+	z.Compress = settings.Compression
+	z.Signer = signingKey(root, settings.Zone, keys)
 
 	return z, wd
 }
 
+// readRecoveryKey reads the zone recovery key from the terminal. The
+// function exits on errors.
+func readRecoveryKey() identity.PrivateKey {
+	text, err := util.ReadPassphrase("Enter zone recovery key", false)
+	if err != nil {
+		fmt.Printf("%s\n", err)
+		os.Exit(1)
+	}
+	key, err := identity.ParseRecoveryKey(string(text))
+	if err != nil {
+		fmt.Printf("%s\n", err)
+		os.Exit(1)
+	}
+	return key
+}
+
+// listSnapshots lists the zone's snapshots from the newest to the
+// oldest. The snapshots are listed from the zone's snapshot index or
+// from the snapshot chain if the zone does not have an index. The
+// snapshots that are listed from the index are not loaded. The
+// function exits on errors.
+func listSnapshots(z *zone.Zone) []objtree.SnapshotRef {
+	if z.Index != nil {
+		return objtree.IndexSnapshots(z.Index)
+	}
+	snapshots, err := objtree.Snapshots(z.HeadID, z)
+	if err != nil {
+		fmt.Printf("Failed to list snapshots: %s\n", err)
+		os.Exit(1)
+	}
+	return snapshots
+}
+
+// loadSnapshots loads the snapshots. The snapshots that can't be
+// loaded are skipped with a warning.
+func loadSnapshots(z *zone.Zone,
+	snapshots []objtree.SnapshotRef) []objtree.SnapshotRef {
+
+	snapshots, errs := objtree.LoadSnapshots(snapshots, z)
+	for _, err := range errs {
+		fmt.Fprintf(os.Stderr, "Skipping snapshot: %s\n", err)
+	}
+	return snapshots
+}
+
+// selectSnapshot resolves the snapshot selector in the zone and
+// loads the selected snapshot. The function exits if the selector
+// does not resolve to a unique snapshot.
+func selectSnapshot(z *zone.Zone, selector string) objtree.SnapshotRef {
+	ref, err := objtree.Select(listSnapshots(z), selector)
+	if err == nil {
+		err = ref.Load(z)
+	}
+	if err != nil {
+		fmt.Printf("%s\n", err)
+		os.Exit(1)
+	}
+	return ref
+}
+
 func main() {
 	flag.Parse()
 
@@ -108,6 +354,13 @@ func main() {
 		fmt.Printf("Unknown command: %s\n", flag.Arg(0))
 		os.Exit(1)
 	}
+	if err := loadSettings(); err != nil {
+		fmt.Printf("Failed to load configuration: %s\n", err)
+		// The config command can fix the invalid values.
+		if flag.Arg(0) != "config" || userConfig == nil {
+			os.Exit(1)
+		}
+	}
 	flag.CommandLine = flag.NewFlagSet(fmt.Sprintf("backup %s", os.Args[0]),
 		flag.ExitOnError)
 	fn()
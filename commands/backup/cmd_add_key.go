@@ -19,10 +19,6 @@ import (
 	"github.com/markkurossi/backup/lib/util"
 )
 
-const (
-	sockEnv = "BACKUP_AGENT_SOCK"
-)
-
 func cmdAddKey() {
 	addAll := flag.Bool("A", false,
 		"Add all identities from your identity storage.")
@@ -98,6 +94,12 @@ func typeName(keyType identity.KeyType) string {
 	case identity.KeyRSAPrivateKey, identity.KeyRSAPublicKey:
 		return "RSA"
 
+	case identity.KeyEd25519PrivateKey, identity.KeyEd25519PublicKey:
+		return "Ed25519"
+
+	case identity.KeyRecovery:
+		return "Recovery"
+
 	default:
 		return keyType.String()
 	}
@@ -0,0 +1,52 @@
+//
+// cmd_browse.go
+//
+// Copyright (c) 2026 Markku Rossi
+//
+// All rights reserved.
+//
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+
+	"github.com/markkurossi/backup/lib/browse"
+	"github.com/markkurossi/backup/lib/objtree"
+)
+
+func cmdBrowse() {
+	listen := flag.String("listen", "localhost:8080", "Listen address.")
+	localhost := flag.Bool("localhost", false,
+		"Bind only to the localhost interface.")
+	flag.Parse()
+
+	addr := *listen
+	if *localhost {
+		_, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			fmt.Printf("Invalid listen address '%s': %s\n", addr, err)
+			os.Exit(1)
+		}
+		addr = net.JoinHostPort("localhost", port)
+	}
+
+	z, _ := openZone()
+	fmt.Printf("Zone '%s' opened\n", z.Name)
+
+	var snapshots []objtree.SnapshotRef
+	if z.Head != nil {
+		snapshots = listSnapshots(z)
+	}
+
+	fmt.Printf("Serving %d snapshots at http://%s/\n", len(snapshots), addr)
+	err := http.ListenAndServe(addr, browse.NewServer(z, snapshots))
+	if err != nil {
+		fmt.Printf("%s\n", err)
+		os.Exit(1)
+	}
+}
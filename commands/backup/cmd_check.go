@@ -0,0 +1,117 @@
+//
+// cmd_check.go
+//
+// Copyright (c) 2026 Markku Rossi
+//
+// All rights reserved.
+//
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/markkurossi/backup/lib/crypto/zone"
+	"github.com/markkurossi/backup/lib/objtree"
+	"github.com/markkurossi/backup/lib/tree"
+)
+
+func cmdCheck() {
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(),
+			"Usage: backup check [options]\n")
+		fmt.Fprintf(flag.CommandLine.Output(), `
+Verify the signatures of the zone root pointer and snapshots with the
+zone's trusted writers, and verify that the content of every object
+that is reachable from the snapshots matches its object ID.
+`)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	z, _ := openZone()
+	fmt.Printf("Zone '%s' opened\n", z.Name)
+
+	writers := trustedWriters(z.Name)
+	if len(writers) == 0 {
+		fmt.Printf("Zone '%s' has no trusted writers, use "+
+			"'backup zone trust' to add them\n", z.Name)
+		os.Exit(1)
+	}
+
+	var failed int
+
+	signer, err := z.VerifyRootPointer(writers)
+	if err != nil {
+		failed++
+	}
+	fmt.Printf("Root pointer: %s\n", signatureStatus(signer, err))
+
+	var snapshots, corrupted int
+	if z.Head != nil {
+		// The objects are read through the zone, which verifies
+		// that their content matches their IDs. The walker and
+		// chunks memoize the verified objects so the objects that
+		// the snapshots share are read only once.
+		walker := objtree.NewWalker(z)
+		walker.Memoize = true
+		chunks := make(map[string]bool)
+
+		for _, ref := range listSnapshots(z) {
+			snapshots++
+			if err := ref.Load(z); err != nil {
+				corrupted++
+				fmt.Printf("Snapshot %s: %s\n", ref.ID, err)
+				continue
+			}
+			signer, err := zone.VerifySnapshot(ref.Snapshot, writers)
+			if err != nil {
+				failed++
+			}
+			fmt.Printf("Snapshot %s %s: %s\n", ref.ID,
+				ref.Time().Format("2006-01-02 15:04:05"),
+				signatureStatus(signer, err))
+
+			err = walker.Walk(ref.ID, func(n *objtree.Node) error {
+				return checkObjects(z, n, chunks)
+			})
+			if err != nil {
+				corrupted++
+				fmt.Printf("Snapshot %s: %s\n", ref.ID, err)
+			}
+		}
+	}
+
+	fmt.Printf("%d snapshots, %d signatures failed, %d snapshots corrupted\n",
+		snapshots, failed, corrupted)
+	if failed > 0 || corrupted > 0 {
+		os.Exit(1)
+	}
+}
+
+// checkObjects reads the content chunks of the file node n. The tree
+// elements are already read by the walker.
+func checkObjects(z *zone.Zone, n *objtree.Node,
+	chunks map[string]bool) error {
+
+	if n.Seen {
+		return nil
+	}
+	file, ok := n.Element.(*tree.ChunkedFile)
+	if !ok {
+		return nil
+	}
+	for _, chunk := range file.Chunks {
+		key := string(chunk.Content.Data)
+		if chunks[key] {
+			continue
+		}
+		if _, err := z.Read(chunk.Content); err != nil {
+			return fmt.Errorf("%s: %s", n.Path, err)
+		}
+		chunks[key] = true
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+//
+// cmd_config.go
+//
+// Copyright (c) 2026 Markku Rossi
+//
+// All rights reserved.
+//
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/markkurossi/backup/lib/config"
+)
+
+func cmdConfig() {
+	repo := flag.Bool("repo", false,
+		"Modify the repository configuration file instead of the user file.")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(),
+			"Usage: backup config [options] get|set|add|unset|list [key] [value]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	args := flag.Args()
+	if len(args) == 0 {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	conf := userConfig
+	if *repo {
+		if repoConfig == nil {
+			fmt.Printf("Repository '%s' has no local configuration file\n",
+				settings.URL)
+			os.Exit(1)
+		}
+		conf = repoConfig
+	}
+
+	switch args[0] {
+	case "get":
+		if len(args) != 2 {
+			flag.Usage()
+			os.Exit(1)
+		}
+		// The repository file takes precedence over the user file.
+		var values []string
+		if repoConfig != nil {
+			values = repoConfig.GetAll(args[1])
+		}
+		if !*repo && len(values) == 0 {
+			values = userConfig.GetAll(args[1])
+		}
+		if len(values) == 0 {
+			os.Exit(1)
+		}
+		for _, v := range values {
+			fmt.Println(v)
+		}
+		return
+
+	case "set", "add":
+		if len(args) != 3 {
+			flag.Usage()
+			os.Exit(1)
+		}
+		err := config.Validate(args[1], args[2])
+		if err != nil {
+			fmt.Printf("Invalid value: %s\n", err)
+			os.Exit(1)
+		}
+		if args[0] == "set" {
+			err = conf.Set(args[1], args[2])
+		} else {
+			err = conf.Add(args[1], args[2])
+		}
+		if err != nil {
+			fmt.Printf("%s\n", err)
+			os.Exit(1)
+		}
+
+	case "unset":
+		if len(args) != 2 {
+			flag.Usage()
+			os.Exit(1)
+		}
+		conf.Unset(args[1])
+
+	case "list":
+		listConfig("user", userConfig)
+		listConfig("repository", repoConfig)
+		fmt.Printf("effective:\n")
+		fmt.Printf("  repository  = %s\n", settings.Repository)
+		fmt.Printf("  url         = %s\n", settings.URL)
+		fmt.Printf("  zone        = %s\n", settings.Zone)
+		fmt.Printf("  agent       = %s\n", settings.AgentSocket)
+		fmt.Printf("  chunk-size  = %d\n", settings.ChunkSize)
+		fmt.Printf("  key-bits    = %d\n", settings.KeyBits)
+		fmt.Printf("  compression = %v\n", settings.Compression)
+		for _, exclude := range settings.Excludes {
+			fmt.Printf("  exclude     = %s\n", exclude)
+		}
+		return
+
+	default:
+		fmt.Printf("Unknown config operation: %s\n", args[0])
+		os.Exit(1)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(conf.Path), 0700); err != nil {
+		fmt.Printf("%s\n", err)
+		os.Exit(1)
+	}
+	if err := conf.Save(); err != nil {
+		fmt.Printf("Failed to save configuration: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+func listConfig(name string, conf *config.Config) {
+	if conf == nil {
+		return
+	}
+	fmt.Printf("%s: %s\n", name, conf.Path)
+	for _, key := range conf.Keys() {
+		for _, v := range conf.GetAll(key) {
+			fmt.Printf("  %s = %s\n", key, v)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+//
+// cmd_du.go
+//
+// Copyright (c) 2026 Markku Rossi
+//
+// All rights reserved.
+//
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path"
+
+	"github.com/markkurossi/backup/lib/objtree"
+)
+
+func cmdDu() {
+	depth := flag.Int("d", 0, "Limit the listing to the directory depth.")
+	all := flag.Bool("a", false, "List files in addition to directories.")
+	sortBy := flag.String("sort", "name", "Sort entries by name or size.")
+	unique := flag.Bool("u", false,
+		"Show stored sizes unique to the subtree and shared with other snapshots.")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(),
+			"Usage: backup du [options] [snapshot] [path]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	opts := &objtree.UsageOptions{
+		MaxDepth: *depth,
+		Files:    *all,
+	}
+	switch *sortBy {
+	case "name":
+	case "size":
+		opts.SortBySize = true
+	default:
+		fmt.Printf("Invalid sort order: %s\n", *sortBy)
+		os.Exit(1)
+	}
+
+	z, _ := openZone()
+	fmt.Printf("Zone '%s' opened\n", z.Name)
+
+	if z.Head == nil {
+		fmt.Printf("No snapshots\n")
+		return
+	}
+	snapshots := listSnapshots(z)
+	ref, err := objtree.Select(snapshots, flag.Arg(0))
+	if err != nil {
+		fmt.Printf("%s\n", err)
+		os.Exit(1)
+	}
+
+	name := path.Clean("./" + flag.Arg(1))
+	node, err := objtree.Lookup(ref.ID, name, z)
+	if err != nil {
+		fmt.Printf("%s\n", err)
+		os.Exit(1)
+	}
+
+	if *unique {
+		var others []objtree.SnapshotRef
+		for _, s := range snapshots {
+			if !s.ID.Equal(ref.ID) {
+				others = append(others, s)
+			}
+		}
+		opts.Sizer = z.StoredSize
+		opts.Shared, err = objtree.Referenced(others, z)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("%10s %10s %10s  %s\n", "Size", "Unique", "Shared", "Path")
+	}
+
+	usage, err := objtree.DiskUsage(node.ID, name, z, opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(1)
+	}
+	printUsage(usage, *unique)
+}
+
+func printUsage(u *objtree.Usage, unique bool) {
+	for _, child := range u.Children {
+		printUsage(child, unique)
+	}
+	if unique {
+		fmt.Printf("%10s %10s %10s  %s\n", u.Size, u.Unique, u.Shared, u.Path)
+	} else {
+		fmt.Printf("%10s  %s\n", u.Size, u.Path)
+	}
+}
@@ -0,0 +1,89 @@
+//
+// cmd_export.go
+//
+// Copyright (c) 2026 Markku Rossi
+//
+// All rights reserved.
+//
+
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path"
+
+	"github.com/markkurossi/backup/lib/archive"
+	"github.com/markkurossi/backup/lib/objtree"
+)
+
+func cmdExport() {
+	formatName := flag.String("format", "tar", "Archive format: tar or zip.")
+	output := flag.String("o", "", "Output file. The default is stdout.")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(),
+			"Usage: backup export [options] [snapshot] [path]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	format, err := archive.ParseFormat(*formatName)
+	if err != nil {
+		fmt.Printf("%s\n", err)
+		os.Exit(1)
+	}
+
+	z, _ := openZone()
+	fmt.Fprintf(os.Stderr, "Zone '%s' opened\n", z.Name)
+
+	if z.Head == nil {
+		fmt.Fprintf(os.Stderr, "No snapshots\n")
+		os.Exit(1)
+	}
+	ref := selectSnapshot(z, flag.Arg(0))
+
+	name := path.Clean("/" + flag.Arg(1))[1:]
+	node, err := objtree.Lookup(ref.ID, name, z)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(1)
+	}
+
+	var out io.Writer
+	var file *os.File
+	if len(*output) > 0 {
+		file, err = os.Create(*output)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s\n", err)
+			os.Exit(1)
+		}
+		out = file
+	} else {
+		out = os.Stdout
+	}
+	w := bufio.NewWriter(out)
+
+	exporter := &archive.Exporter{
+		Format:  format,
+		ModTime: ref.Time(),
+	}
+	if len(name) > 0 {
+		exporter.Name = path.Base(name)
+	}
+	err = exporter.Export(w, node.ID, z)
+	if err == nil {
+		err = w.Flush()
+	}
+	if file != nil {
+		if cerr := file.Close(); err == nil {
+			err = cerr
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Export failed: %s\n", err)
+		os.Exit(1)
+	}
+}
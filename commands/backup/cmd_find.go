@@ -0,0 +1,102 @@
+//
+// cmd_find.go
+//
+// Copyright (c) 2026 Markku Rossi
+//
+// All rights reserved.
+//
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"regexp"
+	"time"
+
+	"github.com/markkurossi/backup/lib/config"
+	"github.com/markkurossi/backup/lib/objtree"
+	"github.com/markkurossi/backup/lib/tree"
+)
+
+func cmdFind() {
+	name := flag.String("name", "", "Find files with names matching the glob.")
+	re := flag.String("regex", "",
+		"Find files with names matching the regular expression.")
+	minSize := flag.String("min-size", "", "Minimum file size.")
+	maxSize := flag.String("max-size", "", "Maximum file size.")
+	newer := flag.String("newer", "", "Find files modified after the time.")
+	older := flag.String("older", "", "Find files modified before the time.")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(),
+			"Usage: backup find [options]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	query := new(objtree.FindQuery)
+	var err error
+
+	query.Name = *name
+	if len(*re) > 0 {
+		query.Regexp, err = regexp.Compile(*re)
+		if err != nil {
+			fmt.Printf("Invalid regular expression: %s\n", err)
+			os.Exit(1)
+		}
+	}
+	if len(*minSize) > 0 {
+		query.MinSize, err = config.ParseSize(*minSize)
+		if err != nil {
+			fmt.Printf("Invalid minimum size: %s\n", err)
+			os.Exit(1)
+		}
+	}
+	if len(*maxSize) > 0 {
+		query.MaxSize, err = config.ParseSize(*maxSize)
+		if err != nil {
+			fmt.Printf("Invalid maximum size: %s\n", err)
+			os.Exit(1)
+		}
+	}
+	if len(*newer) > 0 {
+		query.Newer, err = objtree.ParseTime(*newer)
+		if err != nil {
+			fmt.Printf("%s\n", err)
+			os.Exit(1)
+		}
+	}
+	if len(*older) > 0 {
+		query.Older, err = objtree.ParseTime(*older)
+		if err != nil {
+			fmt.Printf("%s\n", err)
+			os.Exit(1)
+		}
+	}
+
+	z, _ := openZone()
+	fmt.Printf("Zone '%s' opened\n", z.Name)
+
+	if z.Head == nil {
+		fmt.Printf("No snapshots\n")
+		return
+	}
+	matches, err := objtree.Find(loadSnapshots(z, listSnapshots(z)), z, query)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(1)
+	}
+	for _, m := range matches {
+		fmt.Printf("%s\t%s\t%s\n", m.Path, tree.FileSize(m.Size),
+			time.Unix(m.Entry.ModTime, 0).Format(time.Stamp))
+		for idx, s := range m.Snapshots {
+			prefix := "|--"
+			if idx+1 == len(m.Snapshots) {
+				prefix = "`--"
+			}
+			fmt.Printf("%s %s %s\n", prefix, s.ID.String()[:16],
+				s.Time().Format("2006-01-02 15:04:05"))
+		}
+	}
+}
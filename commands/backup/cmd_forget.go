@@ -0,0 +1,59 @@
+//
+// cmd_forget.go
+//
+// Copyright (c) 2026 Markku Rossi
+//
+// All rights reserved.
+//
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/markkurossi/backup/lib/objtree"
+)
+
+func cmdForget() {
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(),
+			"Usage: backup forget snapshot...\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	z, _ := openZone()
+	fmt.Printf("Zone '%s' opened\n", z.Name)
+
+	if z.Head == nil {
+		fmt.Printf("No snapshots\n")
+		os.Exit(1)
+	}
+
+	// Resolve all selectors before modifying the index.
+	snapshots := listSnapshots(z)
+	var refs []objtree.SnapshotRef
+	for _, arg := range flag.Args() {
+		ref, err := objtree.Select(snapshots, arg)
+		if err != nil {
+			fmt.Printf("%s\n", err)
+			os.Exit(1)
+		}
+		refs = append(refs, ref)
+	}
+	for _, ref := range refs {
+		if err := z.ForgetSnapshot(ref.ID); err != nil {
+			fmt.Printf("Failed to forget snapshot: %s\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Forgot snapshot %s (%s)\n", ref.ID,
+			ref.Time().Format("2006-01-02 15:04:05"))
+	}
+}
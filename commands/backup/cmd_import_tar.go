@@ -0,0 +1,61 @@
+//
+// cmd_import_tar.go
+//
+// Copyright (c) 2026 Markku Rossi
+//
+// All rights reserved.
+//
+
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/markkurossi/backup/lib/archive"
+	"github.com/markkurossi/backup/lib/tree"
+)
+
+func cmdImportTar() {
+	message := flag.String("message", "", "Snapshot message.")
+	name := flag.String("name", "-", "Source path recorded in the snapshot.")
+	var tags stringList
+	flag.Var(&tags, "tag", "Snapshot tag. The flag can be repeated.")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(),
+			"Usage: backup import-tar [options] < archive.tar\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	z, _ := openZoneForUpdate()
+	fmt.Printf("Zone '%s' opened\n", z.Name)
+
+	start := time.Now()
+
+	importer := archive.NewImporter(z, settings.ChunkSize)
+	importer.Warn = func(msg string) {
+		fmt.Printf("%s\n", msg)
+	}
+	id, err := importer.ImportTar(bufio.NewReader(os.Stdin))
+	if err != nil {
+		fmt.Printf("Failed to import tar archive: %s\n", err)
+		os.Exit(1)
+	}
+	if importer.Skipped > 0 {
+		fmt.Printf("Skipped %d unsupported entries\n", importer.Skipped)
+	}
+	fmt.Printf("Tree ID: %s\n", id)
+
+	snapshot := newSnapshot(z, id, []string{*name}, *message, tags)
+	snapshot.Stats.Files = importer.Files
+	snapshot.Stats.Dirs = importer.Dirs
+	snapshot.Stats.LogicalSize = tree.FileSize(importer.Size)
+
+	headID := saveSnapshot(z, snapshot, start)
+
+	fmt.Printf("Snapshot: %s\n", headID)
+}
@@ -0,0 +1,65 @@
+//
+// cmd_index.go
+//
+// Copyright (c) 2026 Markku Rossi
+//
+// All rights reserved.
+//
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+func cmdIndex() {
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(),
+			"Usage: backup index list|rebuild\n")
+		fmt.Fprintf(flag.CommandLine.Output(), `
+  list     list the snapshot index entries
+  rebuild  rebuild the snapshot index by scanning all objects; the
+           forgotten snapshots are also added back to the index
+`)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	z, _ := openZone()
+	fmt.Printf("Zone '%s' opened\n", z.Name)
+
+	switch flag.Arg(0) {
+	case "list":
+		if z.Index == nil {
+			fmt.Printf("Zone '%s' has no snapshot index\n", z.Name)
+			os.Exit(1)
+		}
+		fmt.Printf("Index %s\n", z.IndexID)
+		for _, e := range z.Index.Snapshots {
+			fmt.Printf("%s\t%s\t%s\n", e.ID.ToFullString(),
+				time.Unix(0, e.Timestamp).Format("2006-01-02 15:04:05"),
+				strings.Join(e.Tags, ","))
+		}
+
+	case "rebuild":
+		count, err := z.RebuildIndex()
+		if err != nil {
+			fmt.Printf("Failed to rebuild snapshot index: %s\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Indexed %d snapshots, head %s\n", count, z.HeadID)
+
+	default:
+		fmt.Printf("Unknown index operation: %s\n", flag.Arg(0))
+		os.Exit(1)
+	}
+}
@@ -13,6 +13,7 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/markkurossi/backup/lib/config"
 	"github.com/markkurossi/backup/lib/crypto/zone"
 	"github.com/markkurossi/backup/lib/persistence"
 	"github.com/markkurossi/backup/lib/storage"
@@ -40,18 +41,18 @@ func cmdInit() {
 	// XXX select the default key
 	key := keys[0]
 
-	wd, err := os.Getwd()
-	if err != nil {
-		fmt.Printf("Failed to get current working directory: %s\n", err)
+	path, ok := config.FilesystemPath(settings.URL)
+	if !ok {
+		fmt.Printf("Can't initialize remote repository '%s'\n", settings.URL)
 		os.Exit(1)
 	}
-	root, err := persistence.CreateFilesystem(fmt.Sprintf("%s/.backup", wd))
+	root, err := persistence.CreateFilesystem(path)
 	if err != nil {
 		fmt.Printf("%s\n", err)
 		os.Exit(1)
 	}
 
-	z, err := zone.Create(root, "default")
+	z, err := zone.Create(root, settings.Zone)
 	if err != nil {
 		fmt.Printf("%s\n", err)
 		os.Exit(1)
@@ -61,6 +62,7 @@ func cmdInit() {
 		fmt.Printf("Failed to add identity key: %s\n", err)
 		os.Exit(1)
 	}
+	z.Signer, _ = key.(zone.SigningKey)
 	err = z.SetRootPointer(storage.EmptyID)
 	if err != nil {
 		fmt.Printf("Failed to init root pointer: %s\n", err)
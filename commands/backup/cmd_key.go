@@ -0,0 +1,398 @@
+//
+// cmd_key.go
+//
+// Copyright (c) 2026 Markku Rossi
+//
+// All rights reserved.
+//
+
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"os/user"
+	"sort"
+	"strings"
+
+	"github.com/markkurossi/backup/lib/agent"
+	"github.com/markkurossi/backup/lib/crypto/identity"
+	"github.com/markkurossi/backup/lib/crypto/zone"
+	"github.com/markkurossi/backup/lib/util"
+)
+
+func cmdKey() {
+	format := flag.String("format", "pem", "Public key format: pem or text.")
+	kdfName := flag.String("kdf", "argon2id",
+		"Passphrase KDF: argon2id, scrypt, or pbkdf2.")
+	yes := flag.Bool("y", false, "Delete the key without asking.")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(),
+			"Usage: backup key [options] operation [id] [name]\n")
+		fmt.Fprintf(flag.CommandLine.Output(), `
+  list           list the identity keys
+  show id        show the identity key information
+  delete id      delete the identity key
+  rename id name rename the identity key
+  export-public  print the public key of the identity key
+  passwd id      change the passphrase of the identity key
+`)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	switch flag.Arg(0) {
+	case "list":
+		storage := openIdentityStorage()
+		keys, err := storage.List()
+		if err != nil {
+			fmt.Printf("Failed to list keys: %s\n", err)
+			os.Exit(1)
+		}
+		zones := zoneIdentities()
+		agentKeys := agentPublicKeys()
+		for _, info := range keys {
+			created := "-"
+			if !info.Created.IsZero() {
+				created = info.Created.Format("2006-01-02")
+			}
+			fmt.Printf("%s  %s  %s  %s", identity.Fingerprint(info.ID),
+				keyTypeName(info, agentKeys), created, info.Name)
+			opens, _ := keyZones(zones, info.ID)
+			if len(opens) > 0 {
+				fmt.Printf("  [%s]", strings.Join(opens, ","))
+			}
+			fmt.Println()
+		}
+
+	case "show":
+		if flag.NArg() != 2 {
+			flag.Usage()
+			os.Exit(1)
+		}
+		_, info := lookupStoredKey(flag.Arg(1))
+		fmt.Printf("ID:          %s\n", info.ID)
+		fmt.Printf("Fingerprint: %s\n", identity.Fingerprint(info.ID))
+		fmt.Printf("Name:        %s\n", info.Name)
+		fmt.Printf("Type:        %s\n", keyTypeName(info, agentPublicKeys()))
+		if !info.Created.IsZero() {
+			fmt.Printf("Created:     %s\n",
+				info.Created.Format("2006-01-02 15:04:05"))
+		}
+		fmt.Printf("KDF:         %s\n", info.KDF)
+		opens, _ := keyZones(zoneIdentities(), info.ID)
+		fmt.Printf("Zones:       %s\n", strings.Join(opens, ", "))
+
+	case "delete":
+		if flag.NArg() != 2 {
+			flag.Usage()
+			os.Exit(1)
+		}
+		storage, info := lookupStoredKey(flag.Arg(1))
+		_, sole := keyZones(zoneIdentities(), info.ID)
+		if len(sole) > 0 && !*yes {
+			fmt.Printf("Key %s is the only identity of zone %s.\n",
+				info.ID, strings.Join(sole, ", "))
+			fmt.Printf("The zone can't be opened without the key. " +
+				"Delete key? [y/N] ")
+			line, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+			answer := strings.ToLower(strings.TrimSpace(line))
+			if answer != "y" && answer != "yes" {
+				fmt.Printf("Key not deleted\n")
+				return
+			}
+		}
+		if err := storage.Delete(info.ID); err != nil {
+			fmt.Printf("Failed to delete key: %s\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Deleted key %s\n", info.ID)
+
+	case "rename":
+		if flag.NArg() != 3 {
+			flag.Usage()
+			os.Exit(1)
+		}
+		storage, info := lookupStoredKey(flag.Arg(1))
+		passphrase, err := util.ReadPassphrase(
+			fmt.Sprintf("Enter passphrase for key '%s'", info.Name), false)
+		if err != nil {
+			fmt.Printf("%s\n", err)
+			os.Exit(1)
+		}
+		key, err := storage.Load(info.ID, passphrase)
+		if err != nil {
+			fmt.Printf("Failed to load key: %s\n", err)
+			os.Exit(1)
+		}
+		key, err = identity.Rename(key, flag.Arg(2))
+		if err != nil {
+			fmt.Printf("Failed to rename key: %s\n", err)
+			os.Exit(1)
+		}
+		storage.KDF = info.KDF
+		if err := storage.Save(key, passphrase); err != nil {
+			fmt.Printf("Failed to save key: %s\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Renamed key %s to '%s'\n", info.ID, key.Name())
+
+	case "export-public":
+		if flag.NArg() != 2 {
+			flag.Usage()
+			os.Exit(1)
+		}
+		key := lookupPublicKey(flag.Arg(1))
+		var data []byte
+		var err error
+		switch *format {
+		case "pem":
+			data, err = identity.MarshalPublicKeyPEM(key)
+		case "text":
+			data, err = identity.MarshalPublicKeyText(key)
+		default:
+			fmt.Printf("Unsupported public key format '%s'\n", *format)
+			os.Exit(1)
+		}
+		if err != nil {
+			fmt.Printf("Failed to marshal public key: %s\n", err)
+			os.Exit(1)
+		}
+		os.Stdout.Write(data)
+
+	case "passwd":
+		if flag.NArg() != 2 {
+			flag.Usage()
+			os.Exit(1)
+		}
+		kdf, err := identity.ParseKDFAlg(*kdfName)
+		if err != nil {
+			fmt.Printf("%s\n", err)
+			os.Exit(1)
+		}
+		storage, info := lookupStoredKey(flag.Arg(1))
+		passphrase, err := util.ReadPassphrase(
+			fmt.Sprintf("Enter old passphrase for key '%s'", info.Name), false)
+		if err != nil {
+			fmt.Printf("%s\n", err)
+			os.Exit(1)
+		}
+		key, err := storage.Load(info.ID, passphrase)
+		if err != nil {
+			fmt.Printf("Failed to load key: %s\n", err)
+			os.Exit(1)
+		}
+		passphrase, err = util.ReadPassphrase("Enter new passphrase", true)
+		if err != nil {
+			fmt.Printf("%s\n", err)
+			os.Exit(1)
+		}
+		storage.KDF = kdf
+		if err := storage.Save(key, passphrase); err != nil {
+			fmt.Printf("Failed to save key: %s\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Passphrase changed for key %s\n", info.ID)
+
+	default:
+		fmt.Printf("Unknown key operation: %s\n", flag.Arg(0))
+		os.Exit(1)
+	}
+}
+
+// matchKeyID tests if the key ID matches the ID prefix. The prefix
+// can be specified with or without the "sha256:" hash prefix.
+func matchKeyID(id, prefix string) bool {
+	if len(prefix) == 0 {
+		return false
+	}
+	if strings.HasPrefix(id, prefix) {
+		return true
+	}
+	idx := strings.IndexByte(id, ':')
+	return idx >= 0 && strings.HasPrefix(id[idx+1:], prefix)
+}
+
+// lookupPublicKey finds the public key of the identity key id from
+// the key agent or from the identity storage. The function exits if
+// the ID prefix does not match exactly one key.
+func lookupPublicKey(id string) identity.PublicKey {
+	var match identity.PrivateKey
+	for _, key := range dialAgentKeys() {
+		if matchKeyID(key.ID(), id) {
+			if match != nil {
+				fmt.Printf("Ambiguous key ID '%s'\n", id)
+				os.Exit(1)
+			}
+			match = key
+		}
+	}
+	if match != nil {
+		return match.PublicKey()
+	}
+
+	storage, info := lookupStoredKey(id)
+	if info.PublicKey != nil {
+		return info.PublicKey
+	}
+	passphrase, err := util.ReadPassphrase(
+		fmt.Sprintf("Enter passphrase for key '%s'", info.Name), false)
+	if err != nil {
+		fmt.Printf("%s\n", err)
+		os.Exit(1)
+	}
+	key, err := storage.Load(info.ID, passphrase)
+	if err != nil {
+		fmt.Printf("Failed to load key: %s\n", err)
+		os.Exit(1)
+	}
+	private, ok := key.(identity.PrivateKey)
+	if !ok {
+		fmt.Printf("Key %s is not a private key\n", info.ID)
+		os.Exit(1)
+	}
+	return private.PublicKey()
+}
+
+// lookupStoredKey finds the key id from the user's identity
+// storage. The function exits if the ID prefix does not match
+// exactly one key.
+func lookupStoredKey(id string) (*identity.Storage, identity.KeyInfo) {
+	storage := openIdentityStorage()
+	keys, err := storage.List()
+	if err != nil {
+		fmt.Printf("Failed to list keys: %s\n", err)
+		os.Exit(1)
+	}
+	var matches []identity.KeyInfo
+	for _, info := range keys {
+		if matchKeyID(info.ID, id) {
+			matches = append(matches, info)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		fmt.Printf("Key '%s' not found\n", id)
+		os.Exit(1)
+	case 1:
+	default:
+		fmt.Printf("Ambiguous key ID '%s'\n", id)
+		os.Exit(1)
+	}
+	return storage, matches[0]
+}
+
+// openIdentityStorage opens the user's identity storage. The function
+// exits on errors.
+func openIdentityStorage() *identity.Storage {
+	user, err := user.Current()
+	if err != nil {
+		fmt.Printf("Failed to get current user: %s\n", err)
+		os.Exit(1)
+	}
+	storage := identity.NewStorage(user)
+	if err := storage.Open(); err != nil {
+		fmt.Printf("Failed to open identity storage %s: %s\n",
+			storage.Dir, err)
+		os.Exit(1)
+	}
+	return storage
+}
+
+// agentPublicKeys returns the public keys of the key agent's keys
+// by their IDs. The function returns nil if the agent is not
+// available.
+func agentPublicKeys() map[string]identity.PublicKey {
+	keys := dialAgentKeys()
+	if len(keys) == 0 {
+		return nil
+	}
+	result := make(map[string]identity.PublicKey)
+	for _, key := range keys {
+		result[key.ID()] = key.PublicKey()
+	}
+	return result
+}
+
+// dialAgentKeys lists the identity keys of the key agents. The
+// agents that are not available are skipped.
+func dialAgentKeys() []identity.PrivateKey {
+	var result []identity.PrivateKey
+	for _, path := range settings.AgentSockets() {
+		c, err := agent.NewClient(path)
+		if err != nil {
+			continue
+		}
+		keys, err := c.ListKeys()
+		if err != nil {
+			continue
+		}
+		result = append(result, keys...)
+	}
+	return result
+}
+
+// keyTypeName returns the type and size of the key. The type is
+// resolved from the agent keys if the key was saved without its
+// public key.
+func keyTypeName(info identity.KeyInfo,
+	agentKeys map[string]identity.PublicKey) string {
+
+	key := info.PublicKey
+	if key == nil {
+		key = agentKeys[info.ID]
+	}
+	if key == nil {
+		return "unknown"
+	}
+	return fmt.Sprintf("%s-%d", typeName(key.Type()), key.Size())
+}
+
+// zoneIdentities returns the identity IDs of the repository's zones.
+// The function returns nil if the repository can't be opened or if
+// its zones can't be listed.
+func zoneIdentities() map[string][]string {
+	root, err := openPersistence()
+	if err != nil {
+		return nil
+	}
+	names, err := zone.List(root)
+	if err != nil {
+		return nil
+	}
+	result := make(map[string][]string)
+	for _, name := range names {
+		ids, err := zone.IdentityIDs(root, name)
+		if err != nil {
+			continue
+		}
+		result[name] = ids
+	}
+	return result
+}
+
+// keyZones returns the zones that the key id opens and the zones
+// where the key is the only identity.
+func keyZones(zones map[string][]string, id string) (opens, sole []string) {
+	for name, ids := range zones {
+		for _, zid := range ids {
+			if zid == id {
+				opens = append(opens, name)
+				if len(ids) == 1 {
+					sole = append(sole, name)
+				}
+				break
+			}
+		}
+	}
+	sort.Strings(opens)
+	sort.Strings(sole)
+	return
+}
@@ -19,6 +19,10 @@ import (
 )
 
 func cmdKeygen() {
+	keyType := flag.String("t", "ed25519", "Key type: ed25519 or rsa.")
+	bits := flag.Int("b", 0, "RSA key size in bits.")
+	importPath := flag.String("import", "",
+		"Import the identity key from the OpenSSH private key file.")
 	flag.Parse()
 
 	user, err := user.Current()
@@ -26,14 +30,15 @@ func cmdKeygen() {
 		fmt.Printf("Failed to get current user: %s\n", err)
 		os.Exit(1)
 	}
-	bits := 4096
-	fmt.Printf("Creating %d bit RSA key...\n", bits)
-	key, err := identity.NewRSAKey(user.Username, bits)
-	if err != nil {
-		fmt.Printf("Identity key generation failed: %s\n", err)
-		os.Exit(1)
+	var key identity.PrivateKey
+	if len(*importPath) > 0 {
+		key = importKey(user.Username, *importPath)
+		fmt.Printf("Imported %s-%d identity key %s\n",
+			typeName(key.Type()), key.Size(), key.ID())
+	} else {
+		key = generateKey(user.Username, *keyType, *bits)
+		fmt.Printf("Created identity key %s\n", key.ID())
 	}
-	fmt.Printf("Created identity key %s\n", key.ID())
 
 	storage := identity.NewStorage(user)
 	if err := storage.Open(); err != nil {
@@ -54,3 +59,53 @@ func cmdKeygen() {
 		os.Exit(1)
 	}
 }
+
+func generateKey(name, keyType string, bits int) identity.PrivateKey {
+	var key identity.PrivateKey
+	var err error
+
+	switch keyType {
+	case "ed25519":
+		fmt.Printf("Creating Ed25519 key...\n")
+		key, err = identity.NewEd25519Key(name)
+
+	case "rsa":
+		if bits == 0 {
+			bits = settings.KeyBits
+		}
+		fmt.Printf("Creating %d bit RSA key...\n", bits)
+		key, err = identity.NewRSAKey(name, bits)
+
+	default:
+		fmt.Printf("Unsupported key type '%s'\n", keyType)
+		os.Exit(1)
+	}
+	if err != nil {
+		fmt.Printf("Identity key generation failed: %s\n", err)
+		os.Exit(1)
+	}
+	return key
+}
+
+func importKey(name, path string) identity.PrivateKey {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		fmt.Printf("Failed to read key file: %s\n", err)
+		os.Exit(1)
+	}
+	key, err := identity.ParseOpenSSHKey(name, data, nil)
+	if err == identity.ErrPassphraseMissing {
+		passphrase, err2 := util.ReadPassphrase(
+			fmt.Sprintf("Enter passphrase for '%s'", path), false)
+		if err2 != nil {
+			fmt.Printf("%s\n", err2)
+			os.Exit(1)
+		}
+		key, err = identity.ParseOpenSSHKey(name, data, passphrase)
+	}
+	if err != nil {
+		fmt.Printf("Failed to import key '%s': %s\n", path, err)
+		os.Exit(1)
+	}
+	return key
+}
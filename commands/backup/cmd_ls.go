@@ -11,7 +11,9 @@ package main
 import (
 	"flag"
 	"fmt"
+	"os"
 
+	"github.com/markkurossi/backup/lib/crypto/zone"
 	"github.com/markkurossi/backup/lib/objtree"
 )
 
@@ -19,27 +21,85 @@ func cmdLs() {
 	snapshot := flag.Bool("s", false, "List snapshots.")
 	long := flag.Bool("l", false, "List in long format.")
 	debug := flag.Bool("d", false, "Enable debugging.")
+	host := flag.String("host", "", "List snapshots of the host.")
+	username := flag.String("user", "", "List snapshots of the user.")
+	path := flag.String("path", "", "List snapshots of the source path.")
+	jsonOutput := flag.Bool("json", false,
+		"Print newline-delimited JSON output.")
+	var tags stringList
+	flag.Var(&tags, "tag", "List snapshots with the tag.")
 	flag.Parse()
 
 	if *debug {
 		fmt.Printf("Debugging enabled\n")
 	}
 
-	z, _ := openZone("default")
-	fmt.Printf("Zone '%s' opened\n", z.Name)
+	z, _ := openZone()
+	if !*jsonOutput {
+		fmt.Printf("Zone '%s' opened\n", z.Name)
+	}
+
+	if z.Head == nil {
+		if !*jsonOutput {
+			fmt.Printf("No snapshots\n")
+		}
+		return
+	}
 
 	var err error
 
-	id := z.HeadID
+	snapshots := listSnapshots(z)
+	ref, err := objtree.Select(snapshots, flag.Arg(0))
+	if err != nil {
+		fmt.Printf("%s\n", err)
+		os.Exit(1)
+	}
+	id := ref.ID
 
 	if *snapshot {
-		// List snapshots.
-		err = objtree.ListSnapshots(id, z, *long)
+		// List the selected snapshot and the snapshots before it.
+		for idx, s := range snapshots {
+			if s.ID.Equal(id) {
+				snapshots = snapshots[idx:]
+				break
+			}
+		}
+		filter := &objtree.SnapshotFilter{
+			Hostname: *host,
+			Username: *username,
+			Path:     *path,
+			Tags:     tags,
+		}
+		// The snapshots are loaded only if the output or the
+		// signature verification needs more than the index data.
+		writers := trustedWriters(z.Name)
+		if *long || *jsonOutput || len(writers) > 0 || filter.Metadata() {
+			snapshots = loadSnapshots(z, snapshots)
+		}
+		// Verify the snapshot signatures with the trusted writers.
+		if len(writers) > 0 {
+			if !*jsonOutput {
+				signer, err := z.VerifyRootPointer(writers)
+				fmt.Printf("Root pointer: %s\n", signatureStatus(signer, err))
+			}
+			for idx, s := range snapshots {
+				signer, err := zone.VerifySnapshot(s.Snapshot, writers)
+				snapshots[idx].Status = signatureStatus(signer, err)
+			}
+		}
+		if *jsonOutput {
+			err = objtree.ListSnapshotsJSON(os.Stdout, snapshots, filter)
+		} else {
+			objtree.ListSnapshots(snapshots, *long, filter)
+		}
+	} else if *jsonOutput {
+		err = objtree.ListJSON(os.Stdout, id, z)
 	} else {
 		// List files.
 		err = objtree.List(id, z, *long)
 	}
 	if err != nil {
-		fmt.Printf("%s\n", err)
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(1)
 	}
 }
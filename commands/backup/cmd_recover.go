@@ -0,0 +1,108 @@
+//
+// cmd_recover.go
+//
+// Copyright (c) 2026 Markku Rossi
+//
+// All rights reserved.
+//
+
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/markkurossi/backup/lib/crypto/zone"
+)
+
+func cmdRecover() {
+	scan := flag.Bool("scan", false,
+		"Scan all objects for the newest snapshot. This can be slow.")
+	yes := flag.Bool("y", false, "Rewrite the root pointer without asking.")
+	dryRun := flag.Bool("n", false, "Only report the found root pointers.")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(),
+			"Usage: backup recover [options]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	z, _ := openZoneWith(zone.Unlock)
+	requireFullIdentity(z)
+	fmt.Printf("Zone '%s' unlocked\n", z.Name)
+
+	candidates := z.RecoveryCandidates(*scan)
+
+	var best *zone.RecoveryCandidate
+	for _, c := range candidates {
+		if !c.Valid() {
+			fmt.Printf("%-16s invalid: %s\n", c.Source, c.Err)
+			continue
+		}
+		if c.Pointer.Undefined() {
+			fmt.Printf("%-16s %s  empty zone\n", c.Source,
+				time.Unix(0, c.Timestamp).Format("2006-01-02 15:04:05"))
+		} else {
+			fmt.Printf("%-16s %s  snapshot %s (%s)\n", c.Source,
+				time.Unix(0, c.Timestamp).Format("2006-01-02 15:04:05"),
+				c.Pointer,
+				time.Unix(0, c.Snapshot.Timestamp).Format("2006-01-02 15:04:05"))
+		}
+		if best == nil || newerCandidate(c, best) {
+			best = c
+		}
+	}
+	if best == nil {
+		fmt.Printf("No valid root pointers found")
+		if !*scan {
+			fmt.Printf(", try again with -scan")
+		}
+		fmt.Println()
+		os.Exit(1)
+	}
+
+	fmt.Printf("Newest root pointer: %s\n", best.Source)
+	if *dryRun {
+		return
+	}
+	if !*yes {
+		fmt.Printf("Rewrite root pointer? [y/N] ")
+		line, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		answer := strings.ToLower(strings.TrimSpace(line))
+		if answer != "y" && answer != "yes" {
+			fmt.Printf("Root pointer not modified\n")
+			return
+		}
+	}
+	if err := z.Recover(best); err != nil {
+		fmt.Printf("Failed to recover root pointer: %s\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Root pointer set to snapshot %s\n", z.HeadID)
+	if z.Index == nil && z.Head != nil {
+		fmt.Printf("Use 'backup index rebuild' to rebuild the snapshot index\n")
+	}
+}
+
+// newerCandidate tests if the candidate a is newer than the candidate
+// b. The root pointer copies and the log entries are ordered by their
+// root pointer timestamps so that the root pointers that were written
+// after forgetting snapshots win the older log entries. The scanned
+// candidate has no root pointer and it is compared by the head
+// snapshot timestamps.
+func newerCandidate(a, b *zone.RecoveryCandidate) bool {
+	if !a.Scanned && !b.Scanned {
+		return a.Timestamp > b.Timestamp
+	}
+	if a.Snapshot == nil {
+		return false
+	}
+	if b.Snapshot == nil {
+		return true
+	}
+	return a.Snapshot.Timestamp > b.Snapshot.Timestamp
+}
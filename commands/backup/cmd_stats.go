@@ -0,0 +1,98 @@
+//
+// cmd_stats.go
+//
+// Copyright (c) 2026 Markku Rossi
+//
+// All rights reserved.
+//
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/markkurossi/backup/lib/objtree"
+	"github.com/markkurossi/backup/lib/tree"
+)
+
+func cmdStats() {
+	all := flag.Bool("a", false, "Show repository-wide statistics.")
+	jsonOutput := flag.Bool("json", false, "Print JSON output.")
+	flag.Parse()
+
+	z, _ := openZone()
+	if !*jsonOutput {
+		fmt.Printf("Zone '%s' opened\n", z.Name)
+	}
+
+	if z.Head == nil {
+		if !*jsonOutput {
+			fmt.Printf("No snapshots\n")
+			return
+		}
+		fmt.Fprintf(os.Stderr, "No snapshots\n")
+		os.Exit(1)
+	}
+	ref := selectSnapshot(z, flag.Arg(0))
+
+	var stats *objtree.RepositoryStats
+	var err error
+	if *all {
+		stats, err = objtree.Stats(loadSnapshots(z, listSnapshots(z)), z,
+			z.StoredSize)
+		if err != nil {
+			fmt.Fprintf(os.Stderr,
+				"Failed to compute repository statistics: %s\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if *jsonOutput {
+		out := struct {
+			Snapshot   *objtree.SnapshotInfo        `json:"snapshot"`
+			Repository *objtree.RepositoryStatsInfo `json:"repository,omitempty"`
+		}{
+			Snapshot: objtree.NewSnapshotInfo(ref),
+		}
+		if stats != nil {
+			out.Repository = objtree.NewRepositoryStatsInfo(stats)
+		}
+		if err := json.NewEncoder(os.Stdout).Encode(&out); err != nil {
+			fmt.Fprintf(os.Stderr, "%s\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	printStats(ref.ID.String(), ref.Snapshot)
+	if stats == nil {
+		return
+	}
+	fmt.Printf("Repository\n")
+	fmt.Printf("|-- Snapshots   : %d\n", stats.Snapshots)
+	fmt.Printf("|-- Logical size: %s\n", stats.LogicalSize)
+	fmt.Printf("|-- Objects     : %d\n", stats.Objects)
+	fmt.Printf("|-- Stored size : %s\n", stats.StoredSize)
+	fmt.Printf("`-- Dedup ratio : %.2f\n", stats.DedupRatio())
+}
+
+func printStats(id string, s *tree.Snapshot) {
+	fmt.Printf("Snapshot %s\n", id)
+	fmt.Printf("|-- Created     : %s\n", time.Unix(0, s.Timestamp))
+	if s.Version < 3 {
+		fmt.Printf("`-- Size        : %s\n", s.Size)
+		return
+	}
+	fmt.Printf("|-- Files       : %d\n", s.Stats.Files)
+	fmt.Printf("|-- Directories : %d\n", s.Stats.Dirs)
+	fmt.Printf("|-- Logical size: %s\n", s.Stats.LogicalSize)
+	fmt.Printf("|-- New data    : %s\n", s.Stats.NewBytes)
+	fmt.Printf("|-- New objects : %d\n", s.Stats.NewObjects)
+	fmt.Printf("|-- Deduplicated: %s\n", s.Stats.DedupBytes)
+	fmt.Printf("|-- Compressed  : %s\n", s.Stats.CompressedBytes)
+	fmt.Printf("`-- Elapsed     : %s\n", time.Duration(s.Stats.Elapsed))
+}
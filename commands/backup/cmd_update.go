@@ -9,30 +9,61 @@
 package main
 
 import (
+	"bufio"
 	"flag"
 	"fmt"
 	"os"
 	"time"
 
 	"github.com/markkurossi/backup/lib/local"
+	"github.com/markkurossi/backup/lib/storage"
 	"github.com/markkurossi/backup/lib/tree"
 )
 
 func cmdUpdate() {
 	debug := flag.Bool("d", false, "Enable debugging.")
+	message := flag.String("message", "", "Snapshot message.")
+	stdin := flag.Bool("stdin", false,
+		"Back up data from stdin as a single file snapshot.")
+	stdinName := flag.String("stdin-name", "stdin",
+		"File name for the data read from stdin.")
+	var tags stringList
+	flag.Var(&tags, "tag", "Snapshot tag. The flag can be repeated.")
 	flag.Parse()
 
+	if *stdin && !local.ValidFileName(*stdinName) {
+		fmt.Printf("Invalid -stdin-name '%s'\n", *stdinName)
+		os.Exit(1)
+	}
+
 	if *debug {
 		fmt.Printf("Debugging enabled\n")
 	}
 
-	z, root := openZone("default")
+	z, root := openZoneForUpdate()
 	fmt.Printf("Zone '%s' opened\n", z.Name)
 
-	id, err := local.Traverse(root, z)
-	if err != nil {
-		fmt.Printf("Failed to traverse directory '%s': %s\n", root, err)
-		os.Exit(1)
+	start := time.Now()
+
+	traverser := local.NewTraverser(z)
+	traverser.ChunkSize = settings.ChunkSize
+	traverser.Excludes = settings.Excludes
+
+	var id storage.ID
+	var err error
+	if *stdin {
+		root = *stdinName
+		id, err = traverser.TraverseReader(root, bufio.NewReader(os.Stdin))
+		if err != nil {
+			fmt.Printf("Failed to read stdin: %s\n", err)
+			os.Exit(1)
+		}
+	} else {
+		id, err = traverser.Traverse(root)
+		if err != nil {
+			fmt.Printf("Failed to traverse directory '%s': %s\n", root, err)
+			os.Exit(1)
+		}
 	}
 	if id.Undefined() {
 		fmt.Printf("Zone root '%s' is not a directory\n", root)
@@ -49,33 +80,12 @@ func cmdUpdate() {
 		os.Exit(0)
 	}
 
-	snapshot := tree.NewSnapshot()
-	snapshot.Timestamp = time.Now().UnixNano()
-	snapshot.Size = tree.FileSize(z.Written)
-	snapshot.Root = id
-	if z.Head != nil {
-		snapshot.Parent = z.HeadID
-	}
+	snapshot := newSnapshot(z, id, []string{root}, *message, tags)
+	snapshot.Stats.Files = traverser.Files
+	snapshot.Stats.Dirs = traverser.Dirs
+	snapshot.Stats.LogicalSize = tree.FileSize(traverser.Size)
 
-	data, err := snapshot.Serialize()
-	if err != nil {
-		fmt.Printf("Failed to serialize snapshot: %s\n", err)
-		os.Exit(1)
-	}
-	headID, err := z.Write(data)
-	if err != nil {
-		fmt.Printf("Failed to write snapshot: %s\n", err)
-		os.Exit(1)
-	}
-
-	z.Head = snapshot
-	z.HeadID = headID
-
-	err = z.SetRootPointer(headID)
-	if err != nil {
-		fmt.Printf("Failed to save snapshot: %s\n", err)
-		os.Exit(1)
-	}
+	headID := saveSnapshot(z, snapshot, start)
 
 	fmt.Printf("Snapshot: %s\n", headID)
 }
@@ -11,15 +11,46 @@ package main
 import (
 	"flag"
 	"fmt"
+	"os"
+	"strings"
 
+	"github.com/markkurossi/backup/lib/config"
 	"github.com/markkurossi/backup/lib/crypto/identity"
+	"github.com/markkurossi/backup/lib/crypto/zone"
 )
 
 func cmdZone() {
 	addID := flag.String("a", "", "Add identity")
+	noRekey := flag.Bool("no-rekey", false,
+		"Do not rekey the zone after revoking identities.")
+	force := flag.Bool("f", false,
+		"Revoke the identity even if none of your keys can open the zone.")
+	threshold := flag.Int("k", 2, "Number of shares needed to open the zone.")
+	exclusive := flag.Bool("exclusive", false,
+		"Remove the zone identities after splitting the zone secret.")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(),
+			"Usage: backup zone [options] [operation]\n")
+		fmt.Fprintf(flag.CommandLine.Output(), `
+  add-identity file|key  add the exported public key as a zone identity
+  list-identities        list the zone identities
+  revoke key-id          revoke the identity and rekey the zone
+  rekey                  create a new zone secret for the identities
+  recovery-key           create a new zone recovery key
+  split key...           split the zone secret into shares for the keys,
+                         with -exclusive only the shares open the zone
+  add-writer file|key|key-id
+                         add the key as a write-only identity
+  remove-writer key-id   remove the write-only identity
+  trust file|key|key-id  add the key to the zone's trusted writers
+  untrust key-id         remove the key from the zone's trusted writers
+  list-writers           list the zone's trusted writers
+`)
+		flag.PrintDefaults()
+	}
 	flag.Parse()
 
-	z, _ := openZone("default")
+	z, _ := openZone()
 	fmt.Printf("Zone '%s' opened\n", z.Name)
 
 	if len(*addID) > 0 {
@@ -39,4 +70,343 @@ func cmdZone() {
 			z.AddIdentity(key)
 		}
 	}
+	if flag.NArg() == 0 {
+		return
+	}
+
+	switch flag.Arg(0) {
+	case "add-identity":
+		if flag.NArg() != 2 {
+			flag.Usage()
+			os.Exit(1)
+		}
+		// The argument is either a public key file or the public key.
+		data, err := os.ReadFile(flag.Arg(1))
+		if err != nil {
+			data = []byte(flag.Arg(1))
+		}
+		key, err := identity.ParsePublicKey(data)
+		if err != nil {
+			fmt.Printf("Invalid public key: %s\n", err)
+			os.Exit(1)
+		}
+		if err := z.AddIdentity(key); err != nil {
+			fmt.Printf("Failed to add identity: %s\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Added %s-%d identity %s %s\n", typeName(key.Type()),
+			key.Size(), key.ID(), key.Name())
+
+	case "list-identities":
+		ids, err := z.Identities()
+		if err != nil {
+			fmt.Printf("Failed to list identities: %s\n", err)
+			os.Exit(1)
+		}
+		for _, id := range ids {
+			if identity.IsRecoveryID(id.ID) {
+				fmt.Printf("%s\trecovery key\n", id.ID)
+				continue
+			}
+			if id.Key == nil {
+				fmt.Printf("%s\tunknown\n", id.ID)
+				continue
+			}
+			fmt.Printf("%s\t%s-%d\t%s\n", id.ID, typeName(id.Key.Type()),
+				id.Key.Size(), id.Key.Name())
+		}
+		holders, err := z.ShareHolders()
+		if err != nil {
+			fmt.Printf("Failed to list share holders: %s\n", err)
+			os.Exit(1)
+		}
+		for _, holder := range holders {
+			fmt.Printf("%s\tshare %d of %d\t%s-%d\t%s\n", holder.ID,
+				holder.Threshold, holder.Count, typeName(holder.Key.Type()),
+				holder.Key.Size(), holder.Key.Name())
+		}
+		writers, err := z.Writers()
+		if err != nil {
+			fmt.Printf("Failed to list write-only identities: %s\n", err)
+			os.Exit(1)
+		}
+		for _, writer := range writers {
+			kind := "write-only"
+			if writer.Revoked {
+				kind = "write-only, removed"
+			}
+			fmt.Printf("%s\t%s\t%s-%d\t%s\n", writer.ID, kind,
+				typeName(writer.Key.Type()), writer.Key.Size(),
+				writer.Key.Name())
+		}
+
+	case "revoke":
+		if flag.NArg() != 2 {
+			flag.Usage()
+			os.Exit(1)
+		}
+		ids, err := z.Identities()
+		if err != nil {
+			fmt.Printf("Failed to list identities: %s\n", err)
+			os.Exit(1)
+		}
+		var revoke string
+		var remaining []string
+		for _, id := range ids {
+			if matchKeyID(id.ID, flag.Arg(1)) {
+				if len(revoke) > 0 {
+					fmt.Printf("Ambiguous key ID '%s'\n", flag.Arg(1))
+					os.Exit(1)
+				}
+				revoke = id.ID
+			} else {
+				remaining = append(remaining, id.ID)
+			}
+		}
+		if len(revoke) == 0 {
+			fmt.Printf("Identity '%s' not found\n", flag.Arg(1))
+			os.Exit(1)
+		}
+		if !*force && !canOpen(remaining) {
+			fmt.Printf("None of your keys can open the zone after revoking "+
+				"%s, use -f to revoke anyway\n", revoke)
+			os.Exit(1)
+		}
+		if err := z.Revoke(revoke); err != nil {
+			fmt.Printf("Failed to revoke identity: %s\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Revoked identity %s\n", revoke)
+		if *noRekey {
+			fmt.Printf("Use 'backup zone rekey' to rekey the zone\n")
+			return
+		}
+		rekeyZone(z)
+
+	case "rekey":
+		rekeyZone(z)
+
+	case "recovery-key":
+		key, text, err := identity.NewRecoveryKey()
+		if err != nil {
+			fmt.Printf("Failed to create recovery key: %s\n", err)
+			os.Exit(1)
+		}
+		if err := z.SetRecoveryKey(key); err != nil {
+			fmt.Printf("Failed to set recovery key: %s\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Zone '%s' recovery key:\n\n    %s\n\n", z.Name, text)
+		fmt.Printf("Store the recovery key in a safe place. It opens the " +
+			"zone when none of\nyour identity keys are available. The " +
+			"previous recovery key of the zone\nwas removed.\n")
+
+	case "split":
+		if flag.NArg() < 2 {
+			flag.Usage()
+			os.Exit(1)
+		}
+		var keys []identity.PublicKey
+		for _, arg := range flag.Args()[1:] {
+			keys = append(keys, publicKeyArg(arg))
+		}
+		if err := z.Split(*threshold, keys, *exclusive); err != nil {
+			fmt.Printf("Failed to split zone secret: %s\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Zone '%s' secret split into %d shares, %d needed to "+
+			"open the zone:\n", z.Name, len(keys), *threshold)
+		for _, key := range keys {
+			fmt.Printf("  %s %s\n", key.ID(), key.Name())
+		}
+		if !*exclusive {
+			return
+		}
+		fmt.Printf("Removed the zone identities\n")
+		if *noRekey {
+			fmt.Printf("Use 'backup zone rekey' to rekey the zone\n")
+			return
+		}
+		rekeyZone(z)
+
+	case "add-writer":
+		if flag.NArg() != 2 {
+			flag.Usage()
+			os.Exit(1)
+		}
+		key := publicKeyArg(flag.Arg(1))
+		if err := z.AddWriter(key); err != nil {
+			fmt.Printf("Failed to add write-only identity: %s\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Added %s-%d write-only identity %s %s\n",
+			typeName(key.Type()), key.Size(), key.ID(), key.Name())
+
+	case "remove-writer":
+		if flag.NArg() != 2 {
+			flag.Usage()
+			os.Exit(1)
+		}
+		ids, err := zone.WriterIDs(z.Persistence, z.Name)
+		if err != nil {
+			fmt.Printf("Failed to list write-only identities: %s\n", err)
+			os.Exit(1)
+		}
+		var remove []string
+		for _, id := range ids {
+			if matchKeyID(id, flag.Arg(1)) {
+				remove = append(remove, id)
+			}
+		}
+		switch len(remove) {
+		case 0:
+			fmt.Printf("Write-only identity '%s' not found\n", flag.Arg(1))
+			os.Exit(1)
+		case 1:
+		default:
+			fmt.Printf("Ambiguous key ID '%s'\n", flag.Arg(1))
+			os.Exit(1)
+		}
+		if err := z.RemoveWriter(remove[0]); err != nil {
+			fmt.Printf("Failed to remove write-only identity: %s\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Removed write-only identity %s\n", remove[0])
+
+	case "trust":
+		if flag.NArg() != 2 {
+			flag.Usage()
+			os.Exit(1)
+		}
+		key := publicKeyArg(flag.Arg(1))
+		if _, ok := key.(identity.Verifier); !ok {
+			fmt.Printf("Key %s can't verify signatures\n", key.ID())
+			os.Exit(1)
+		}
+		for _, writer := range trustedWriters(z.Name) {
+			if writer.ID() == key.ID() {
+				fmt.Printf("Key %s is already a trusted writer\n", key.ID())
+				return
+			}
+		}
+		data, err := identity.MarshalPublicKeyText(key)
+		if err != nil {
+			fmt.Printf("Failed to marshal public key: %s\n", err)
+			os.Exit(1)
+		}
+		err = userConfig.Add(config.ZoneTrustedWriterKey(z.Name),
+			strings.TrimSpace(string(data)))
+		if err == nil {
+			err = userConfig.Save()
+		}
+		if err != nil {
+			fmt.Printf("Failed to save trusted writers: %s\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Added trusted writer %s %s\n", key.ID(), key.Name())
+
+	case "untrust":
+		if flag.NArg() != 2 {
+			flag.Usage()
+			os.Exit(1)
+		}
+		var keep []string
+		var removed []identity.PublicKey
+		for _, writer := range trustedWriters(z.Name) {
+			if matchKeyID(writer.ID(), flag.Arg(1)) {
+				removed = append(removed, writer)
+				continue
+			}
+			data, err := identity.MarshalPublicKeyText(writer)
+			if err != nil {
+				fmt.Printf("Failed to marshal public key: %s\n", err)
+				os.Exit(1)
+			}
+			keep = append(keep, strings.TrimSpace(string(data)))
+		}
+		switch len(removed) {
+		case 0:
+			fmt.Printf("Trusted writer '%s' not found\n", flag.Arg(1))
+			os.Exit(1)
+		case 1:
+		default:
+			fmt.Printf("Ambiguous key ID '%s'\n", flag.Arg(1))
+			os.Exit(1)
+		}
+		key := config.ZoneTrustedWriterKey(z.Name)
+		userConfig.Unset(key)
+		for _, v := range keep {
+			userConfig.Add(key, v)
+		}
+		if err := userConfig.Save(); err != nil {
+			fmt.Printf("Failed to save trusted writers: %s\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Removed trusted writer %s\n", removed[0].ID())
+
+	case "list-writers":
+		for _, writer := range trustedWriters(z.Name) {
+			fmt.Printf("%s\t%s-%d\t%s\n", writer.ID(),
+				typeName(writer.Type()), writer.Size(), writer.Name())
+		}
+
+	default:
+		fmt.Printf("Unknown zone operation: %s\n", flag.Arg(0))
+		os.Exit(1)
+	}
+}
+
+// canOpen tests if any of the agent keys is one of the identities
+// ids.
+func canOpen(ids []string) bool {
+	keys, err := listAgentKeys()
+	if err != nil {
+		return false
+	}
+	for _, key := range keys {
+		for _, id := range ids {
+			if key.ID() == id {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// rekeyZone rekeys the zone. The function exits on errors.
+func rekeyZone(z *zone.Zone) {
+	keys, err := listAgentKeys()
+	if err != nil {
+		fmt.Printf("Failed to get identity keys: %s\n", err)
+		os.Exit(1)
+	}
+	var pubs []identity.PublicKey
+	for _, key := range keys {
+		pubs = append(pubs, key.PublicKey())
+	}
+	recovery, _ := zone.HasRecoveryKey(z.Persistence, z.Name)
+	if err := z.Rekey(pubs); err != nil {
+		fmt.Printf("Failed to rekey zone: %s\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Zone '%s' rekeyed\n", z.Name)
+	if recovery {
+		fmt.Printf("The zone recovery key was removed, use " +
+			"'backup zone recovery-key' to create a new one\n")
+	}
+}
+
+// publicKeyArg resolves the public key from the exported public key
+// file, the exported public key, or the key ID. The function exits on
+// errors.
+func publicKeyArg(arg string) identity.PublicKey {
+	data, err := os.ReadFile(arg)
+	if err != nil {
+		data = []byte(arg)
+	}
+	key, err := identity.ParsePublicKey(data)
+	if err == nil {
+		return key
+	}
+	return lookupPublicKey(arg)
 }
@@ -0,0 +1,82 @@
+//
+// snapshot.go
+//
+// Copyright (c) 2026 Markku Rossi
+//
+// All rights reserved.
+//
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"time"
+
+	"github.com/markkurossi/backup/lib/crypto/zone"
+	"github.com/markkurossi/backup/lib/storage"
+	"github.com/markkurossi/backup/lib/tree"
+)
+
+// newSnapshot creates a new snapshot of the tree root. The snapshot
+// parent is the zone's current head snapshot.
+func newSnapshot(z *zone.Zone, root storage.ID, paths []string,
+	message string, tags []string) *tree.Snapshot {
+
+	snapshot := tree.NewSnapshot()
+	snapshot.Timestamp = time.Now().UnixNano()
+	snapshot.Root = root
+	if z.Head != nil {
+		snapshot.Parent = z.HeadID
+	}
+	snapshot.Meta.Hostname, _ = os.Hostname()
+	if u, err := user.Current(); err == nil {
+		snapshot.Meta.Username = u.Username
+	}
+	snapshot.Meta.Paths = paths
+	snapshot.Meta.ToolVersion = version
+	snapshot.Meta.Tags = tags
+	snapshot.Meta.Message = message
+
+	return snapshot
+}
+
+// saveSnapshot completes the snapshot statistics from the zone
+// counters, signs the snapshot with the zone's signing key, writes
+// the snapshot, and adds it to the zone's snapshot index. The start
+// specifies when the snapshot creation was started. The function
+// exits on errors.
+func saveSnapshot(z *zone.Zone, snapshot *tree.Snapshot,
+	start time.Time) storage.ID {
+
+	snapshot.Size = snapshot.Stats.LogicalSize
+	snapshot.Stats.NewBytes = tree.FileSize(z.Written)
+	snapshot.Stats.NewObjects = int64(z.Objects)
+	snapshot.Stats.DedupBytes = tree.FileSize(z.Dedup)
+	snapshot.Stats.CompressedBytes = tree.FileSize(z.Saved)
+	snapshot.Stats.Elapsed = int64(time.Since(start))
+
+	if err := z.SignSnapshot(snapshot); err != nil {
+		fmt.Printf("%s\n", err)
+		os.Exit(1)
+	}
+
+	data, err := snapshot.Serialize()
+	if err != nil {
+		fmt.Printf("Failed to serialize snapshot: %s\n", err)
+		os.Exit(1)
+	}
+	headID, err := z.Write(data)
+	if err != nil {
+		fmt.Printf("Failed to write snapshot: %s\n", err)
+		os.Exit(1)
+	}
+
+	err = z.AddSnapshot(headID, snapshot)
+	if err != nil {
+		fmt.Printf("Failed to save snapshot: %s\n", err)
+		os.Exit(1)
+	}
+	return headID
+}
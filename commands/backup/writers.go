@@ -0,0 +1,90 @@
+//
+// writers.go
+//
+// Copyright (c) 2026 Markku Rossi
+//
+// All rights reserved.
+//
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/markkurossi/backup/lib/config"
+	"github.com/markkurossi/backup/lib/crypto/identity"
+	"github.com/markkurossi/backup/lib/crypto/zone"
+	"github.com/markkurossi/backup/lib/persistence"
+)
+
+// trustedWriters returns the trusted writers of the zone name. The
+// trusted writers are read only from the user configuration file
+// because the repository configuration file can be modified by
+// anyone who can write to the repository. The function exits on
+// errors.
+func trustedWriters(name string) []identity.PublicKey {
+	var result []identity.PublicKey
+	for _, v := range userConfig.GetAll(config.ZoneTrustedWriterKey(name)) {
+		key, err := identity.ParsePublicKey([]byte(v))
+		if err != nil {
+			fmt.Printf("%s: invalid trusted writer of zone '%s': %s\n",
+				userConfig.Path, name, err)
+			os.Exit(1)
+		}
+		result = append(result, key)
+	}
+	return result
+}
+
+// signingKey selects the key that signs the snapshots and root
+// pointers of the zone name. The function prefers the zone's trusted
+// writers, then the zone identities, and finally the write-only
+// identities. The function returns nil if none of the keys can sign
+// for the zone.
+func signingKey(p persistence.Reader, name string,
+	keys []identity.PrivateKey) zone.SigningKey {
+
+	var signers []identity.PrivateKey
+	for _, key := range keys {
+		if _, ok := key.(zone.SigningKey); ok {
+			signers = append(signers, key)
+		}
+	}
+	for _, writer := range trustedWriters(name) {
+		for _, key := range signers {
+			if key.ID() == writer.ID() {
+				return key.(zone.SigningKey)
+			}
+		}
+	}
+	ids, err := zone.IdentityIDs(p, name)
+	if err != nil {
+		return nil
+	}
+	writers, _ := zone.WriterIDs(p, name)
+	ids = append(ids, writers...)
+	for _, id := range ids {
+		for _, key := range signers {
+			if key.ID() == id {
+				return key.(zone.SigningKey)
+			}
+		}
+	}
+	return nil
+}
+
+// signatureStatus describes the signature verification result.
+func signatureStatus(signer string, err error) string {
+	switch {
+	case err == nil:
+		return fmt.Sprintf("good signature by %s", signer)
+	case errors.Is(err, zone.ErrNotSigned):
+		return "unsigned"
+	case errors.Is(err, zone.ErrUntrusted):
+		return fmt.Sprintf("untrusted signer %s", signer)
+	default:
+		return fmt.Sprintf("BAD signature: %s", err)
+	}
+}
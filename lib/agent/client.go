@@ -142,6 +142,29 @@ func (key *proxyKey) Decrypt(ciphertext []byte) ([]byte, error) {
 	}
 }
 
+func (key *proxyKey) Sign(msg []byte) ([]byte, error) {
+	reply, err := RPC(key.client.conn, &MsgSign{
+		MsgHdr: MsgHdr{
+			t: Sign,
+		},
+		KeyID: key.info.ID,
+		Data:  msg,
+	})
+	if err != nil {
+		return nil, err
+	}
+	switch m := reply.(type) {
+	case *MsgError:
+		return nil, errors.New(m.Message)
+
+	case *MsgSignature:
+		return m.Signature, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported agent message '%s'", reply.Type())
+	}
+}
+
 func (key *proxyKey) PublicKey() identity.PublicKey {
 	return key.publicKey
 }
@@ -31,6 +31,8 @@ const (
 	Keys              = 6
 	Decrypt           = 7
 	Decrypted         = 8
+	Sign              = 9
+	Signature         = 10
 )
 
 var msgTypeNames = map[MsgType]string{
@@ -43,6 +45,8 @@ var msgTypeNames = map[MsgType]string{
 	Keys:      "keys",
 	Decrypt:   "decrypt",
 	Decrypted: "decrypted",
+	Sign:      "sign",
+	Signature: "signature",
 }
 
 func (t MsgType) String() string {
@@ -141,6 +145,19 @@ type MsgDecrypted struct {
 	Data []byte
 }
 
+// MsgSign implements the data signing message.
+type MsgSign struct {
+	MsgHdr
+	KeyID string
+	Data  []byte
+}
+
+// MsgSignature implements the signature message.
+type MsgSignature struct {
+	MsgHdr
+	Signature []byte
+}
+
 // RPC sends the mssage msg to the connection and returns the response
 // message.
 func RPC(conn net.Conn, msg Msg) (Msg, error) {
@@ -221,6 +238,12 @@ func ReceiveMessage(conn net.Conn) (Msg, error) {
 	case Decrypted:
 		msg = new(MsgDecrypted)
 
+	case Sign:
+		msg = new(MsgSign)
+
+	case Signature:
+		msg = new(MsgSignature)
+
 	default:
 		return nil, fmt.Errorf("protocol: unexpected message: %s",
 			MsgType(hdr[0]))
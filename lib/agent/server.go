@@ -84,6 +84,16 @@ func (c *Connection) SendDecrypted(data []byte) error {
 	})
 }
 
+// SendSignature sends the signature to the connection.
+func (c *Connection) SendSignature(signature []byte) error {
+	return SendMessage(c.conn, &MsgSignature{
+		MsgHdr: MsgHdr{
+			t: Signature,
+		},
+		Signature: signature,
+	})
+}
+
 func (c *Connection) messageLoop() {
 	for {
 		msg, err := ReceiveMessage(c.conn)
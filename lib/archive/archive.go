@@ -0,0 +1,202 @@
+//
+// Copyright (c) 2026 Markku Rossi
+//
+// All rights reserved.
+//
+
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/markkurossi/backup/lib/objtree"
+	"github.com/markkurossi/backup/lib/storage"
+)
+
+// Format defines archive formats.
+type Format int
+
+// Archive formats.
+const (
+	FormatTar Format = iota
+	FormatZip
+)
+
+var formats = map[string]Format{
+	"tar": FormatTar,
+	"zip": FormatZip,
+}
+
+func (f Format) String() string {
+	for name, format := range formats {
+		if format == f {
+			return name
+		}
+	}
+	return fmt.Sprintf("{Format %d}", f)
+}
+
+// ParseFormat parses the archive format name.
+func ParseFormat(name string) (Format, error) {
+	format, ok := formats[strings.ToLower(name)]
+	if !ok {
+		return 0, fmt.Errorf("unknown archive format '%s'", name)
+	}
+	return format, nil
+}
+
+// Exporter exports object trees as archives.
+type Exporter struct {
+	Format Format
+	// Name is the archive name of the root element. If the name is
+	// empty, the entries of the root directory are stored at the
+	// top-level of the archive.
+	Name string
+	// ModTime is the modification time of the root element.
+	ModTime time.Time
+}
+
+type archiver interface {
+	dir(name string, mode os.FileMode, modTime time.Time) error
+	file(name string, mode os.FileMode, modTime time.Time, size int64,
+		r io.Reader) error
+	Close() error
+}
+
+// Export exports the object tree root into the writer w. The root
+// can be a snapshot, a directory, or a file.
+func (e *Exporter) Export(w io.Writer, root storage.ID,
+	st storage.Accessor) error {
+
+	var a archiver
+	switch e.Format {
+	case FormatTar:
+		a = &tarArchiver{
+			w: tar.NewWriter(w),
+		}
+	case FormatZip:
+		a = &zipArchiver{
+			w: zip.NewWriter(w),
+		}
+	default:
+		return fmt.Errorf("unsupported archive format %s", e.Format)
+	}
+
+	err := objtree.NewWalker(st).Walk(root, func(n *objtree.Node) error {
+		if n.Snapshot() != nil {
+			return nil
+		}
+		name := e.Name
+		if len(n.Path) > 0 {
+			if len(name) > 0 {
+				name += "/"
+			}
+			name += n.Path
+		}
+		mode := os.FileMode(0644)
+		modTime := e.ModTime
+		if n.Entry != nil {
+			mode = n.Entry.Mode
+			modTime = time.Unix(n.Entry.ModTime, 0)
+		}
+		if n.Element.IsDir() {
+			if len(name) == 0 {
+				return nil
+			}
+			if n.Entry == nil {
+				mode = os.ModeDir | 0755
+			}
+			return a.dir(name, mode, modTime)
+		}
+		if len(name) == 0 {
+			return fmt.Errorf("file name not specified")
+		}
+		file := n.Element.File()
+		return a.file(name, mode, modTime, file.Size(), file.Reader())
+	})
+	if err != nil {
+		a.Close()
+		return err
+	}
+	return a.Close()
+}
+
+type tarArchiver struct {
+	w *tar.Writer
+}
+
+func (a *tarArchiver) dir(name string, mode os.FileMode,
+	modTime time.Time) error {
+
+	return a.w.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeDir,
+		Name:     name + "/",
+		Mode:     int64(mode.Perm()),
+		ModTime:  modTime,
+	})
+}
+
+func (a *tarArchiver) file(name string, mode os.FileMode, modTime time.Time,
+	size int64, r io.Reader) error {
+
+	err := a.w.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Mode:     int64(mode.Perm()),
+		ModTime:  modTime,
+		Size:     size,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(a.w, r)
+	return err
+}
+
+func (a *tarArchiver) Close() error {
+	return a.w.Close()
+}
+
+type zipArchiver struct {
+	w *zip.Writer
+}
+
+func (a *zipArchiver) dir(name string, mode os.FileMode,
+	modTime time.Time) error {
+
+	hdr := &zip.FileHeader{
+		Name:     name + "/",
+		Modified: modTime,
+	}
+	hdr.SetMode(mode)
+	_, err := a.w.CreateHeader(hdr)
+	return err
+}
+
+func (a *zipArchiver) file(name string, mode os.FileMode, modTime time.Time,
+	size int64, r io.Reader) error {
+
+	hdr := &zip.FileHeader{
+		Name:               name,
+		Method:             zip.Deflate,
+		Modified:           modTime,
+		UncompressedSize64: uint64(size),
+	}
+	hdr.SetMode(mode)
+	w, err := a.w.CreateHeader(hdr)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, r)
+	return err
+}
+
+func (a *zipArchiver) Close() error {
+	return a.w.Close()
+}
@@ -0,0 +1,221 @@
+//
+// Copyright (c) 2026 Markku Rossi
+//
+// All rights reserved.
+//
+
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/markkurossi/backup/lib/storage"
+	"github.com/markkurossi/backup/lib/tree"
+)
+
+func write(t *testing.T, st storage.Writer, el tree.Element) storage.ID {
+	data, err := el.Serialize()
+	if err != nil {
+		t.Fatalf("Failed to serialize element: %v", err)
+	}
+	id, err := st.Write(data)
+	if err != nil {
+		t.Fatalf("Failed to write element: %v", err)
+	}
+	return id
+}
+
+func makeTree(t *testing.T, st storage.Accessor) storage.ID {
+	chunk1, _ := st.Write([]byte("hello, "))
+	chunk2, _ := st.Write([]byte("world"))
+	cf := tree.NewChunkedFile(12)
+	cf.Add(7, chunk1)
+	cf.Add(5, chunk2)
+
+	lib := tree.NewDirectory()
+	lib.Add("a.txt", 0600, 1000, write(t, st, cf))
+	root := tree.NewDirectory()
+	root.Add("lib", os.ModeDir|0755, 1000, write(t, st, lib))
+	root.Add("README", 0644, 2000,
+		write(t, st, tree.NewSimpleFile([]byte("readme"))))
+
+	return write(t, st, root)
+}
+
+var expected = map[string]string{
+	"top/":          "",
+	"top/lib/":      "",
+	"top/lib/a.txt": "hello, world",
+	"top/README":    "readme",
+}
+
+func TestTar(t *testing.T) {
+	st := storage.NewMemory()
+	root := makeTree(t, st)
+
+	var buf bytes.Buffer
+	e := &Exporter{
+		Format: FormatTar,
+		Name:   "top",
+	}
+	if err := e.Export(&buf, root, st); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	checkTar(t, &buf)
+}
+
+func TestImportTar(t *testing.T) {
+	st := storage.NewMemory()
+	root := makeTree(t, st)
+
+	var buf bytes.Buffer
+	e := &Exporter{
+		Format: FormatTar,
+	}
+	if err := e.Export(&buf, root, st); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+
+	imp := NewImporter(st, 8)
+	imported, err := imp.ImportTar(&buf)
+	if err != nil {
+		t.Fatalf("ImportTar failed: %v", err)
+	}
+	if imp.Files != 2 || imp.Dirs != 2 || imp.Size != 18 {
+		t.Errorf("got %d files, %d dirs, %d bytes", imp.Files, imp.Dirs,
+			imp.Size)
+	}
+
+	buf.Reset()
+	e.Name = "top"
+	if err := e.Export(&buf, imported, st); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	checkTar(t, &buf)
+
+	_, err = imp.ImportTar(tarOf(t, "../etc/passwd"))
+	if err == nil {
+		t.Errorf("ImportTar accepted a relative parent path")
+	}
+}
+
+func TestImportTarSkipped(t *testing.T) {
+	var buf bytes.Buffer
+	w := tar.NewWriter(&buf)
+	for _, hdr := range []*tar.Header{
+		{Typeflag: tar.TypeReg, Name: "a.txt", Mode: 0644},
+		{Typeflag: tar.TypeSymlink, Name: "b.txt", Linkname: "a.txt"},
+		{Typeflag: tar.TypeLink, Name: "c.txt", Linkname: "a.txt"},
+	} {
+		if err := w.WriteHeader(hdr); err != nil {
+			t.Fatalf("WriteHeader failed: %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	var warnings []string
+	imp := NewImporter(storage.NewMemory(), 8)
+	imp.Warn = func(msg string) {
+		warnings = append(warnings, msg)
+	}
+	if _, err := imp.ImportTar(&buf); err != nil {
+		t.Fatalf("ImportTar failed: %v", err)
+	}
+	if imp.Files != 1 || imp.Skipped != 2 || len(warnings) != 2 {
+		t.Errorf("got %d files, %d skipped, warnings %v", imp.Files,
+			imp.Skipped, warnings)
+	}
+}
+
+func tarOf(t *testing.T, name string) io.Reader {
+	var buf bytes.Buffer
+	w := tar.NewWriter(&buf)
+	err := w.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Mode:     0644,
+	})
+	if err != nil {
+		t.Fatalf("WriteHeader failed: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	return &buf
+}
+
+func checkTar(t *testing.T, in io.Reader) {
+	r := tar.NewReader(in)
+	var count int
+	for {
+		hdr, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("tar.Next failed: %v", err)
+		}
+		data, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatalf("tar read failed: %v", err)
+		}
+		content, ok := expected[hdr.Name]
+		if !ok {
+			t.Errorf("unexpected entry %s", hdr.Name)
+			continue
+		}
+		if string(data) != content {
+			t.Errorf("%s: got %q, expected %q", hdr.Name, data, content)
+		}
+		if hdr.Name == "top/lib/a.txt" &&
+			(hdr.Mode != 0600 || hdr.ModTime.Unix() != 1000) {
+			t.Errorf("%s: mode %o, mtime %v", hdr.Name, hdr.Mode, hdr.ModTime)
+		}
+		count++
+	}
+	if count != len(expected) {
+		t.Errorf("got %d entries, expected %d", count, len(expected))
+	}
+}
+
+func TestZip(t *testing.T) {
+	st := storage.NewMemory()
+	root := makeTree(t, st)
+
+	var buf bytes.Buffer
+	e := &Exporter{
+		Format: FormatZip,
+		Name:   "top",
+	}
+	if err := e.Export(&buf, root, st); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	r, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("zip.NewReader failed: %v", err)
+	}
+	if len(r.File) != len(expected) {
+		t.Errorf("got %d entries, expected %d", len(r.File), len(expected))
+	}
+	for _, f := range r.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("%s: open failed: %v", f.Name, err)
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatalf("%s: read failed: %v", f.Name, err)
+		}
+		if string(data) != expected[f.Name] {
+			t.Errorf("%s: got %q, expected %q", f.Name, data, expected[f.Name])
+		}
+	}
+}
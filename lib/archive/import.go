@@ -0,0 +1,217 @@
+//
+// Copyright (c) 2026 Markku Rossi
+//
+// All rights reserved.
+//
+
+package archive
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/markkurossi/backup/lib/storage"
+	"github.com/markkurossi/backup/lib/tree"
+)
+
+// Importer imports tar archives into the storage writer.
+type Importer struct {
+	Writer    storage.Writer
+	ChunkSize int64
+	Files     int64
+	Dirs      int64
+	Size      int64
+	Skipped   int64
+	// Warn is called with the warning message of each skipped
+	// archive entry. If Warn is nil, the entries are skipped
+	// silently.
+	Warn func(msg string)
+}
+
+// NewImporter creates a new importer for the writer.
+func NewImporter(writer storage.Writer, chunkSize int64) *Importer {
+	return &Importer{
+		Writer:    writer,
+		ChunkSize: chunkSize,
+	}
+}
+
+type importNode struct {
+	mode     os.FileMode
+	modTime  int64
+	id       storage.ID
+	children map[string]*importNode
+}
+
+func newImportDir() *importNode {
+	return &importNode{
+		mode:     os.ModeDir | 0755,
+		children: make(map[string]*importNode),
+	}
+}
+
+// ImportTar reads the tar archive from the reader r and stores its
+// content into the importer's writer. The file contents are stored
+// as they are read from the archive and the directories are stored
+// after the archive is read. Entries other than regular files and
+// directories are skipped with a warning. The function returns the
+// ID of the archive's root directory.
+func (imp *Importer) ImportTar(r io.Reader) (storage.ID, error) {
+	root := newImportDir()
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return storage.ID{}, err
+		}
+		name, err := cleanName(hdr.Name)
+		if err != nil {
+			return storage.ID{}, err
+		}
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if len(name) == 0 {
+				root.mode = os.ModeDir | os.FileMode(hdr.Mode).Perm()
+				root.modTime = hdr.ModTime.Unix()
+				continue
+			}
+			dir, base, err := root.parent(name)
+			if err != nil {
+				return storage.ID{}, err
+			}
+			n, ok := dir.children[base]
+			if !ok {
+				n = newImportDir()
+				dir.children[base] = n
+			}
+			if n.children == nil {
+				return storage.ID{}, fmt.Errorf("%s: not a directory", name)
+			}
+			n.mode = os.ModeDir | os.FileMode(hdr.Mode).Perm()
+			n.modTime = hdr.ModTime.Unix()
+
+		case tar.TypeReg, tar.TypeRegA:
+			if len(name) == 0 {
+				return storage.ID{}, fmt.Errorf("invalid file name '%s'",
+					hdr.Name)
+			}
+			dir, base, err := root.parent(name)
+			if err != nil {
+				return storage.ID{}, err
+			}
+			if n, ok := dir.children[base]; ok && n.children != nil {
+				return storage.ID{}, fmt.Errorf("%s: is a directory", name)
+			}
+			id, size, err := tree.StoreFile(tr, imp.ChunkSize, imp.Writer)
+			if err != nil {
+				return storage.ID{}, err
+			}
+			dir.children[base] = &importNode{
+				mode:    os.FileMode(hdr.Mode).Perm(),
+				modTime: hdr.ModTime.Unix(),
+				id:      id,
+			}
+			imp.Files++
+			imp.Size += size
+
+		case tar.TypeXGlobalHeader:
+			// Archive metadata.
+
+		default:
+			imp.Skipped++
+			if imp.Warn != nil {
+				imp.Warn(fmt.Sprintf("%s: skipping unsupported %s",
+					hdr.Name, typeName(hdr.Typeflag)))
+			}
+		}
+	}
+	return imp.store(root)
+}
+
+// typeName returns the description of the tar entry type.
+func typeName(typeflag byte) string {
+	switch typeflag {
+	case tar.TypeLink:
+		return "hard link"
+	case tar.TypeSymlink:
+		return "symbolic link"
+	case tar.TypeChar:
+		return "character device"
+	case tar.TypeBlock:
+		return "block device"
+	case tar.TypeFifo:
+		return "FIFO"
+	default:
+		return fmt.Sprintf("entry type '%c'", typeflag)
+	}
+}
+
+// store stores the directory node and its subdirectories.
+func (imp *Importer) store(n *importNode) (storage.ID, error) {
+	imp.Dirs++
+
+	var names []string
+	for name := range n.children {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	dir := tree.NewDirectory()
+	for _, name := range names {
+		child := n.children[name]
+		if child.children != nil {
+			id, err := imp.store(child)
+			if err != nil {
+				return id, err
+			}
+			child.id = id
+		}
+		dir.Add(name, child.mode, child.modTime, child.id)
+	}
+	data, err := dir.Serialize()
+	if err != nil {
+		return storage.ID{}, err
+	}
+	return imp.Writer.Write(data)
+}
+
+// parent returns the parent directory node of the slash-separated
+// path and the path's base name. The missing parent directories are
+// created.
+func (n *importNode) parent(name string) (*importNode, string, error) {
+	parts := strings.Split(name, "/")
+	for _, part := range parts[:len(parts)-1] {
+		child, ok := n.children[part]
+		if !ok {
+			child = newImportDir()
+			n.children[part] = child
+		}
+		if child.children == nil {
+			return nil, "", fmt.Errorf("%s: not a directory", name)
+		}
+		n = child
+	}
+	return n, parts[len(parts)-1], nil
+}
+
+// cleanName returns the archive entry name relative to the archive
+// root. The root directory has an empty name.
+func cleanName(name string) (string, error) {
+	cleaned := path.Clean("/" + name)
+	if strings.Contains(name, "..") {
+		for _, part := range strings.Split(name, "/") {
+			if part == ".." {
+				return "", fmt.Errorf("invalid entry name '%s'", name)
+			}
+		}
+	}
+	return cleaned[1:], nil
+}
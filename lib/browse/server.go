@@ -0,0 +1,347 @@
+//
+// Copyright (c) 2026 Markku Rossi
+//
+// All rights reserved.
+//
+
+package browse
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/markkurossi/backup/lib/objtree"
+	"github.com/markkurossi/backup/lib/storage"
+	"github.com/markkurossi/backup/lib/tree"
+)
+
+// SnapshotNameLayout defines the time layout of the top-level
+// snapshot directory names.
+const SnapshotNameLayout = "2006-01-02T15-04-05"
+
+// Server implements a read-only HTTP and WebDAV server for browsing
+// snapshots. The top-level directory lists the snapshots by their
+// creation time and each snapshot directory contains the snapshot's
+// root directory.
+type Server struct {
+	st        storage.Accessor
+	snapshots []snapshot
+	byName    map[string]*snapshot
+}
+
+type snapshot struct {
+	name string
+	ref  objtree.SnapshotRef
+}
+
+// NewServer creates a new server for the snapshots.
+func NewServer(st storage.Accessor, snapshots []objtree.SnapshotRef) *Server {
+	s := &Server{
+		st:     st,
+		byName: make(map[string]*snapshot),
+	}
+	for _, ref := range snapshots {
+		name := ref.Time().Format(SnapshotNameLayout)
+		if _, ok := s.byName[name]; ok {
+			name = fmt.Sprintf("%s-%x", name, ref.ID.Data[:4])
+		}
+		s.snapshots = append(s.snapshots, snapshot{
+			name: name,
+			ref:  ref,
+		})
+		s.byName[name] = &s.snapshots[len(s.snapshots)-1]
+	}
+	return s
+}
+
+// resource describes a resolved request path.
+type resource struct {
+	path    string
+	name    string
+	dir     bool
+	size    int64
+	modTime time.Time
+	node    *objtree.Node
+}
+
+// resolve resolves the slash-separated path. The root resource has
+// a nil node and the snapshot resources have nodes for the snapshot
+// root directories.
+func (s *Server) resolve(p string) (*resource, error) {
+	p = path.Clean("/" + p)
+	if p == "/" {
+		var modTime time.Time
+		if len(s.snapshots) > 0 {
+			modTime = s.snapshots[0].ref.Time()
+		}
+		return &resource{
+			path:    p,
+			name:    "/",
+			dir:     true,
+			modTime: modTime,
+		}, nil
+	}
+	parts := strings.SplitN(p[1:], "/", 2)
+	snap, ok := s.byName[parts[0]]
+	if !ok {
+		return nil, fmt.Errorf("%s: not found", p)
+	}
+	var rest string
+	if len(parts) > 1 {
+		rest = parts[1]
+	}
+	n, err := objtree.Lookup(snap.ref.ID, rest, s.st)
+	if err != nil {
+		return nil, err
+	}
+	r := &resource{
+		path:    p,
+		name:    path.Base(p),
+		dir:     n.Element.IsDir(),
+		modTime: snap.ref.Time(),
+		node:    n,
+	}
+	if n.Entry != nil {
+		r.modTime = time.Unix(n.Entry.ModTime, 0)
+	}
+	if !r.dir {
+		r.size = n.Element.File().Size()
+	}
+	return r, nil
+}
+
+// children returns the child resources of the directory resource.
+func (s *Server) children(r *resource) ([]*resource, error) {
+	var result []*resource
+	prefix := r.path
+	if !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	if r.node == nil {
+		for _, snap := range s.snapshots {
+			result = append(result, &resource{
+				path:    prefix + snap.name,
+				name:    snap.name,
+				dir:     true,
+				modTime: snap.ref.Time(),
+			})
+		}
+		return result, nil
+	}
+	dir := r.node.Element.(*tree.Directory)
+	for idx := range dir.Entries {
+		e := &dir.Entries[idx]
+		child := &resource{
+			path:    prefix + e.Name,
+			name:    e.Name,
+			dir:     e.Mode.IsDir(),
+			modTime: time.Unix(e.ModTime, 0),
+		}
+		if !child.dir {
+			el, err := tree.DeserializeID(e.Entry, s.st)
+			if err != nil {
+				return nil, err
+			}
+			child.size = el.File().Size()
+		}
+		result = append(result, child)
+	}
+	return result, nil
+}
+
+// ServeHTTP implements http.Handler.ServeHTTP.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("DAV", "1")
+
+	switch r.Method {
+	case http.MethodOptions:
+		w.Header().Set("Allow", "OPTIONS, GET, HEAD, PROPFIND")
+		w.WriteHeader(http.StatusOK)
+
+	case http.MethodGet, http.MethodHead:
+		res, err := s.resolve(r.URL.Path)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if !res.dir {
+			http.ServeContent(w, r, res.name, res.modTime,
+				res.node.Element.File().RandomReader())
+			return
+		}
+		if !strings.HasSuffix(r.URL.Path, "/") {
+			http.Redirect(w, r, r.URL.Path+"/", http.StatusMovedPermanently)
+			return
+		}
+		s.serveDir(w, r, res)
+
+	case "PROPFIND":
+		s.propfind(w, r)
+
+	default:
+		w.Header().Set("Allow", "OPTIONS, GET, HEAD, PROPFIND")
+		http.Error(w, "read-only file system", http.StatusMethodNotAllowed)
+	}
+}
+
+var dirTemplate = template.Must(template.New("dir").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Path}}</title>
+</head>
+<body>
+<h1>{{.Path}}</h1>
+<table>
+{{- if ne .Path "/"}}
+<tr><td><a href="../">../</a></td><td></td><td></td></tr>
+{{- end}}
+{{- range .Entries}}
+<tr><td><a href="{{.Href}}">{{.Name}}</a></td><td>{{.Size}}</td><td>{{.ModTime}}</td></tr>
+{{- end}}
+</table>
+</body>
+</html>
+`))
+
+type dirEntry struct {
+	Name    string
+	Href    string
+	Size    string
+	ModTime string
+}
+
+func (s *Server) serveDir(w http.ResponseWriter, r *http.Request,
+	res *resource) {
+
+	children, err := s.children(res)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	var entries []dirEntry
+	for _, child := range children {
+		e := dirEntry{
+			Name:    child.name,
+			Href:    (&url.URL{Path: child.name}).String(),
+			ModTime: child.modTime.Format("2006-01-02 15:04:05"),
+		}
+		if child.dir {
+			e.Name += "/"
+			e.Href += "/"
+		} else {
+			e.Size = tree.FileSize(child.size).String()
+		}
+		entries = append(entries, e)
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if r.Method == http.MethodHead {
+		return
+	}
+	err = dirTemplate.Execute(w, struct {
+		Path    string
+		Entries []dirEntry
+	}{
+		Path:    res.path,
+		Entries: entries,
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+type multistatus struct {
+	XMLName   xml.Name   `xml:"D:multistatus"`
+	XMLNS     string     `xml:"xmlns:D,attr"`
+	Responses []response `xml:"D:response"`
+}
+
+type response struct {
+	Href     string   `xml:"D:href"`
+	Propstat propstat `xml:"D:propstat"`
+}
+
+type propstat struct {
+	Prop   prop   `xml:"D:prop"`
+	Status string `xml:"D:status"`
+}
+
+type prop struct {
+	DisplayName   string        `xml:"D:displayname"`
+	ResourceType  *resourceType `xml:"D:resourcetype"`
+	ContentLength *int64        `xml:"D:getcontentlength,omitempty"`
+	LastModified  string        `xml:"D:getlastmodified"`
+}
+
+type resourceType struct {
+	Collection *struct{} `xml:"D:collection,omitempty"`
+}
+
+func newResponse(r *resource) response {
+	resp := response{
+		Href: (&url.URL{Path: r.path}).String(),
+		Propstat: propstat{
+			Prop: prop{
+				DisplayName:  r.name,
+				ResourceType: new(resourceType),
+				LastModified: r.modTime.UTC().Format(http.TimeFormat),
+			},
+			Status: "HTTP/1.1 200 OK",
+		},
+	}
+	if r.dir {
+		if !strings.HasSuffix(resp.Href, "/") {
+			resp.Href += "/"
+		}
+		resp.Propstat.Prop.ResourceType.Collection = &struct{}{}
+	} else {
+		size := r.size
+		resp.Propstat.Prop.ContentLength = &size
+	}
+	return resp
+}
+
+// propfind implements the WebDAV PROPFIND method. All requests are
+// handled as allprop requests and depth infinity is handled as
+// depth 1.
+func (s *Server) propfind(w http.ResponseWriter, r *http.Request) {
+	res, err := s.resolve(r.URL.Path)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	ms := &multistatus{
+		XMLNS: "DAV:",
+		Responses: []response{
+			newResponse(res),
+		},
+	}
+	if res.dir && r.Header.Get("Depth") != "0" {
+		children, err := s.children(res)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		for _, child := range children {
+			ms.Responses = append(ms.Responses, newResponse(child))
+		}
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	if err := xml.NewEncoder(&buf).Encode(ms); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.WriteHeader(http.StatusMultiStatus)
+	w.Write(buf.Bytes())
+}
@@ -0,0 +1,126 @@
+//
+// Copyright (c) 2026 Markku Rossi
+//
+// All rights reserved.
+//
+
+package browse
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/markkurossi/backup/lib/objtree"
+	"github.com/markkurossi/backup/lib/storage"
+	"github.com/markkurossi/backup/lib/tree"
+)
+
+func write(t *testing.T, st storage.Writer, el tree.Element) storage.ID {
+	data, err := el.Serialize()
+	if err != nil {
+		t.Fatalf("Failed to serialize element: %v", err)
+	}
+	id, err := st.Write(data)
+	if err != nil {
+		t.Fatalf("Failed to write element: %v", err)
+	}
+	return id
+}
+
+var content = bytes.Repeat([]byte("0123456789"), 10)
+
+func newServer(t *testing.T) *httptest.Server {
+	st := storage.NewMemory()
+
+	file, _, err := tree.StoreFile(bytes.NewReader(content), 16, st)
+	if err != nil {
+		t.Fatalf("StoreFile failed: %v", err)
+	}
+	lib := tree.NewDirectory()
+	lib.Add("data.bin", 0644, 1000, file)
+	root := tree.NewDirectory()
+	root.Add("lib", os.ModeDir|0755, 1000, write(t, st, lib))
+
+	s := tree.NewSnapshot()
+	s.Timestamp = time.Date(2026, 5, 1, 10, 0, 0, 0, time.Local).UnixNano()
+	s.Root = write(t, st, root)
+	head := write(t, st, s)
+
+	snapshots, err := objtree.Snapshots(head, st)
+	if err != nil {
+		t.Fatalf("Snapshots failed: %v", err)
+	}
+	return httptest.NewServer(NewServer(st, snapshots))
+}
+
+func request(t *testing.T, method, url string, hdr map[string]string) (
+	*http.Response, string) {
+
+	req, err := http.NewRequest(method, url, nil)
+	if err != nil {
+		t.Fatalf("NewRequest failed: %v", err)
+	}
+	for k, v := range hdr {
+		req.Header.Set(k, v)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s failed: %v", method, url, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+	return resp, string(data)
+}
+
+func TestServer(t *testing.T) {
+	ts := newServer(t)
+	defer ts.Close()
+
+	snapshot := "/2026-05-01T10-00-00"
+
+	resp, body := request(t, "GET", ts.URL+"/", nil)
+	if resp.StatusCode != http.StatusOK ||
+		!strings.Contains(body, `href="2026-05-01T10-00-00/"`) {
+		t.Errorf("GET /: %s\n%s", resp.Status, body)
+	}
+
+	resp, body = request(t, "GET", ts.URL+snapshot+"/lib/", nil)
+	if resp.StatusCode != http.StatusOK ||
+		!strings.Contains(body, `href="data.bin"`) {
+		t.Errorf("GET lib: %s\n%s", resp.Status, body)
+	}
+
+	resp, body = request(t, "GET", ts.URL+snapshot+"/lib/data.bin",
+		map[string]string{"Range": "bytes=14-33"})
+	if resp.StatusCode != http.StatusPartialContent ||
+		body != string(content[14:34]) {
+		t.Errorf("GET range: %s: %q", resp.Status, body)
+	}
+
+	resp, _ = request(t, "GET", ts.URL+snapshot+"/lib/missing", nil)
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("GET missing: %s", resp.Status)
+	}
+
+	resp, body = request(t, "PROPFIND", ts.URL+snapshot+"/lib",
+		map[string]string{"Depth": "1"})
+	if resp.StatusCode != http.StatusMultiStatus ||
+		!strings.Contains(body, "<D:getcontentlength>100<") ||
+		strings.Count(body, "<D:response>") != 2 {
+		t.Errorf("PROPFIND: %s\n%s", resp.Status, body)
+	}
+
+	resp, _ = request(t, "PUT", ts.URL+snapshot+"/lib/new", nil)
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("PUT: %s", resp.Status)
+	}
+}
@@ -0,0 +1,285 @@
+//
+// Copyright (c) 2026 Markku Rossi
+//
+// All rights reserved.
+//
+
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Config implements a configuration file. The file consists of
+// sections and key-value pairs:
+//
+//	# Comment
+//	[defaults]
+//		zone = default
+//		exclude = *.o
+//		exclude = node_modules
+//
+//	[repository "home"]
+//		url = /home/user/.backup
+//
+// The values are addressed with dotted names: section.key or
+// section.subsection.key, for example `defaults.zone' or
+// `repository.home.url'. A key can have multiple values.
+type Config struct {
+	Path   string
+	values []value
+}
+
+type value struct {
+	key   string
+	value string
+}
+
+// New creates an empty configuration for the file path.
+func New(path string) *Config {
+	return &Config{
+		Path: path,
+	}
+}
+
+// Load loads the configuration from the file path. The function
+// returns an empty configuration if the file does not exist.
+func Load(path string) (*Config, error) {
+	conf := New(path)
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return conf, nil
+		}
+		return nil, err
+	}
+	if err := conf.parse(data); err != nil {
+		return nil, err
+	}
+	return conf, nil
+}
+
+func (conf *Config) parse(data []byte) error {
+	var section string
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for lineno := 1; scanner.Scan(); lineno++ {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+		if line[0] == '[' {
+			if line[len(line)-1] != ']' {
+				return fmt.Errorf("%s:%d: unterminated section header",
+					conf.Path, lineno)
+			}
+			name, err := parseSection(line[1 : len(line)-1])
+			if err != nil {
+				return fmt.Errorf("%s:%d: %s", conf.Path, lineno, err)
+			}
+			section = name
+			continue
+		}
+		idx := strings.IndexByte(line, '=')
+		if idx < 0 {
+			return fmt.Errorf("%s:%d: expected key = value", conf.Path, lineno)
+		}
+		if len(section) == 0 {
+			return fmt.Errorf("%s:%d: key outside section", conf.Path, lineno)
+		}
+		key := strings.TrimSpace(line[:idx])
+		if len(key) == 0 {
+			return fmt.Errorf("%s:%d: empty key", conf.Path, lineno)
+		}
+		conf.values = append(conf.values, value{
+			key:   section + "." + strings.ToLower(key),
+			value: unquote(strings.TrimSpace(line[idx+1:])),
+		})
+	}
+	return scanner.Err()
+}
+
+func parseSection(header string) (string, error) {
+	parts := strings.SplitN(strings.TrimSpace(header), " ", 2)
+	name := strings.ToLower(parts[0])
+	if len(name) == 0 {
+		return "", fmt.Errorf("empty section name")
+	}
+	if len(parts) == 1 {
+		return name, nil
+	}
+	sub := strings.TrimSpace(parts[1])
+	if len(sub) < 2 || sub[0] != '"' || sub[len(sub)-1] != '"' {
+		return "", fmt.Errorf("invalid subsection %s", sub)
+	}
+	return name + "." + sub[1:len(sub)-1], nil
+}
+
+func unquote(val string) string {
+	if len(val) >= 2 && val[0] == '"' && val[len(val)-1] == '"' {
+		return val[1 : len(val)-1]
+	}
+	return val
+}
+
+// splitKey splits the dotted key into its section and key name
+// parts.
+func splitKey(key string) (string, string, error) {
+	idx := strings.LastIndexByte(key, '.')
+	if idx <= 0 || idx == len(key)-1 {
+		return "", "", fmt.Errorf("invalid key '%s'", key)
+	}
+	return key[:idx], key[idx+1:], nil
+}
+
+// canonicalKey returns the key with the section and key names in
+// lowercase like parse stores them. The subsection name is case
+// sensitive.
+func canonicalKey(key string) string {
+	section, name, err := splitKey(key)
+	if err != nil {
+		return key
+	}
+	parts := strings.SplitN(section, ".", 2)
+	parts[0] = strings.ToLower(parts[0])
+	return strings.Join(parts, ".") + "." + strings.ToLower(name)
+}
+
+// Get returns the last value of the key.
+func (conf *Config) Get(key string) (string, bool) {
+	key = canonicalKey(key)
+	for i := len(conf.values) - 1; i >= 0; i-- {
+		if conf.values[i].key == key {
+			return conf.values[i].value, true
+		}
+	}
+	return "", false
+}
+
+// GetAll returns all values of the key.
+func (conf *Config) GetAll(key string) []string {
+	key = canonicalKey(key)
+	var result []string
+	for _, v := range conf.values {
+		if v.key == key {
+			result = append(result, v.value)
+		}
+	}
+	return result
+}
+
+// Set sets the value of the key, replacing all its old values.
+func (conf *Config) Set(key, val string) error {
+	if _, _, err := splitKey(key); err != nil {
+		return err
+	}
+	conf.Unset(key)
+	return conf.Add(key, val)
+}
+
+// Add adds a value for the key.
+func (conf *Config) Add(key, val string) error {
+	if _, _, err := splitKey(key); err != nil {
+		return err
+	}
+	conf.values = append(conf.values, value{
+		key:   canonicalKey(key),
+		value: val,
+	})
+	return nil
+}
+
+// Unset removes all values of the key.
+func (conf *Config) Unset(key string) {
+	key = canonicalKey(key)
+	var values []value
+	for _, v := range conf.values {
+		if v.key != key {
+			values = append(values, v)
+		}
+	}
+	conf.values = values
+}
+
+// Keys returns the sorted list of keys that have values.
+func (conf *Config) Keys() []string {
+	seen := make(map[string]bool)
+	var keys []string
+	for _, v := range conf.values {
+		if !seen[v.key] {
+			seen[v.key] = true
+			keys = append(keys, v.key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Subsections returns the subsection names of the section.
+func (conf *Config) Subsections(section string) []string {
+	prefix := section + "."
+	seen := make(map[string]bool)
+	var result []string
+	for _, v := range conf.values {
+		if !strings.HasPrefix(v.key, prefix) {
+			continue
+		}
+		sec, _, err := splitKey(v.key)
+		if err != nil || len(sec) <= len(prefix) {
+			continue
+		}
+		sub := sec[len(prefix):]
+		if !seen[sub] {
+			seen[sub] = true
+			result = append(result, sub)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+// Save writes the configuration to its file.
+func (conf *Config) Save() error {
+	var sections []string
+	bySection := make(map[string][]value)
+
+	for _, v := range conf.values {
+		section, key, err := splitKey(v.key)
+		if err != nil {
+			return err
+		}
+		_, ok := bySection[section]
+		if !ok {
+			sections = append(sections, section)
+		}
+		bySection[section] = append(bySection[section], value{
+			key:   key,
+			value: v.value,
+		})
+	}
+
+	out := new(bytes.Buffer)
+	for idx, section := range sections {
+		if idx > 0 {
+			fmt.Fprintln(out)
+		}
+		parts := strings.SplitN(section, ".", 2)
+		if len(parts) == 1 {
+			fmt.Fprintf(out, "[%s]\n", parts[0])
+		} else {
+			fmt.Fprintf(out, "[%s \"%s\"]\n", parts[0], parts[1])
+		}
+		for _, v := range bySection[section] {
+			fmt.Fprintf(out, "\t%s = %s\n", v.key, v.value)
+		}
+	}
+
+	return ioutil.WriteFile(conf.Path, out.Bytes(), 0600)
+}
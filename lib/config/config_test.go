@@ -0,0 +1,143 @@
+//
+// Copyright (c) 2026 Markku Rossi
+//
+// All rights reserved.
+//
+
+package config
+
+import (
+	"testing"
+)
+
+var testConfig = `# Test configuration.
+[defaults]
+	zone = work
+	exclude = *.o
+	exclude = "node_modules"
+	chunk-size = 4M
+
+[repository "home"]
+	url = file:///home/user/backups
+	credentials = env:BACKUP_TOKEN
+`
+
+func TestParse(t *testing.T) {
+	conf := New("test")
+	if err := conf.parse([]byte(testConfig)); err != nil {
+		t.Fatalf("Failed to parse config: %v", err)
+	}
+	v, ok := conf.Get("repository.home.url")
+	if !ok || v != "file:///home/user/backups" {
+		t.Errorf("Unexpected repository URL: %v", v)
+	}
+	excludes := conf.GetAll(KeyExclude)
+	if len(excludes) != 2 || excludes[1] != "node_modules" {
+		t.Errorf("Unexpected excludes: %v", excludes)
+	}
+	subs := conf.Subsections("repository")
+	if len(subs) != 1 || subs[0] != "home" {
+		t.Errorf("Unexpected subsections: %v", subs)
+	}
+
+	settings := NewSettings()
+	if err := settings.Apply(conf); err != nil {
+		t.Fatalf("Failed to apply config: %v", err)
+	}
+	if settings.Zone != "work" {
+		t.Errorf("Unexpected zone: %s", settings.Zone)
+	}
+	if settings.ChunkSize != 4*1024*1024 {
+		t.Errorf("Unexpected chunk size: %d", settings.ChunkSize)
+	}
+	settings.Repository = "home"
+	settings.Resolve(conf)
+	if settings.URL != "file:///home/user/backups" ||
+		settings.Credentials != "env:BACKUP_TOKEN" {
+		t.Errorf("Failed to resolve repository: %s %s", settings.URL,
+			settings.Credentials)
+	}
+}
+
+func TestSetCase(t *testing.T) {
+	conf := New("test")
+	if err := conf.Set("Defaults.Zone", "work"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if err := conf.Add("Repository.Home.URL", "file:///backups"); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	if v, ok := conf.Get(KeyZone); !ok || v != "work" {
+		t.Errorf("Unexpected zone: %v", v)
+	}
+	if v, ok := conf.Get("repository.Home.url"); !ok || v != "file:///backups" {
+		t.Errorf("Unexpected repository URL: %v", v)
+	}
+	conf.Unset("DEFAULTS.ZONE")
+	if _, ok := conf.Get(KeyZone); ok {
+		t.Errorf("Unset failed")
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, input := range []string{
+		"key = value\n",
+		"[defaults\n",
+		"[defaults]\nkey\n",
+		"[repository home]\n",
+	} {
+		conf := New("test")
+		if err := conf.parse([]byte(input)); err == nil {
+			t.Errorf("Invalid config parsed: %q", input)
+		}
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	for _, test := range []struct {
+		input    string
+		expected int64
+	}{
+		{"512", 512},
+		{"4k", 4 * 1024},
+		{"4K", 4 * 1024},
+		{"4m", 4 * 1024 * 1024},
+		{"4M", 4 * 1024 * 1024},
+		{"2g", 2 * 1024 * 1024 * 1024},
+	} {
+		size, err := ParseSize(test.input)
+		if err != nil || size != test.expected {
+			t.Errorf("ParseSize(%q) = %d, %v, expected %d", test.input,
+				size, err, test.expected)
+		}
+	}
+	for _, input := range []string{"", "foo", "0", "-1k", "9223372036854775807G"} {
+		if _, err := ParseSize(input); err == nil {
+			t.Errorf("ParseSize(%q) succeeded", input)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for _, test := range []struct {
+		key   string
+		value string
+		valid bool
+	}{
+		{KeyChunkSize, "4M", true},
+		{KeyChunkSize, "foo", false},
+		{KeyChunkSize, "1G", false},
+		{"Defaults.Chunk-Size", "foo", false},
+		{KeyKeyBits, "2048", true},
+		{KeyKeyBits, "many", false},
+		{KeyCompression, "off", true},
+		{KeyCompression, "maybe", false},
+		{KeyKeepDaily, "-1", false},
+		{KeyZone, "anything", true},
+	} {
+		err := Validate(test.key, test.value)
+		if (err == nil) != test.valid {
+			t.Errorf("Validate(%s, %q): %v", test.key, test.value, err)
+		}
+	}
+}
@@ -0,0 +1,368 @@
+//
+// Copyright (c) 2026 Markku Rossi
+//
+// All rights reserved.
+//
+
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Default values for settings.
+const (
+	DefaultRepository  = ".backup"
+	DefaultZone        = "default"
+	DefaultChunkSize   = 1024 * 1024
+	DefaultKeyBits     = 4096
+	DefaultCompression = true
+	MaxChunkSize       = 256 * 1024 * 1024
+)
+
+// Environment variables that override configuration file values.
+const (
+	EnvAgentSocket = "BACKUP_AGENT_SOCK"
+	EnvRepository  = "BACKUP_REPOSITORY"
+	EnvZone        = "BACKUP_ZONE"
+)
+
+// Known configuration keys.
+const (
+	KeyRepository  = "defaults.repository"
+	KeyZone        = "defaults.zone"
+	KeyAgentSocket = "defaults.agent-socket"
+	KeyChunkSize   = "defaults.chunk-size"
+	KeyKeyBits     = "defaults.key-bits"
+	KeyExclude     = "defaults.exclude"
+	KeyCompression = "defaults.compression"
+	KeyKeepLast    = "retention.keep-last"
+	KeyKeepDaily   = "retention.keep-daily"
+	KeyKeepWeekly  = "retention.keep-weekly"
+	KeyKeepMonthly = "retention.keep-monthly"
+)
+
+// ZoneTrustedWriterKey returns the configuration key of the zone's
+// trusted writers. The values are the writers' public keys in the
+// exported text format.
+func ZoneTrustedWriterKey(zone string) string {
+	return fmt.Sprintf("zone.%s.trusted-writer", zone)
+}
+
+// Settings define the effective backup settings. The settings are
+// resolved from the following sources, in the order of increasing
+// precedence:
+//
+//  1. built-in defaults
+//  2. user configuration file ~/.backup.d/config
+//  3. repository configuration file <repository>/config
+//  4. environment variables
+//  5. command line flags
+type Settings struct {
+	// Repository is the repository name or URL.
+	Repository string
+	// URL is the repository backend URL.
+	URL string
+	// Credentials is a reference to the repository credentials:
+	// env:NAME for an environment variable and file:PATH for a
+	// file.
+	Credentials string
+	Zone        string
+	AgentSocket string
+	ChunkSize   int64
+	KeyBits     int
+	Excludes    []string
+	Compression bool
+	Retention   Retention
+}
+
+// Retention defines the snapshot retention policy. Zero values mean
+// that the policy is not limited by the count.
+type Retention struct {
+	KeepLast    int
+	KeepDaily   int
+	KeepWeekly  int
+	KeepMonthly int
+}
+
+// UserConfigPath returns the path of the user's configuration file.
+func UserConfigPath(u *user.User) string {
+	return fmt.Sprintf("%s/.backup.d/config", u.HomeDir)
+}
+
+// RepositoryConfigPath returns the path of the repository
+// configuration file for the filesystem repository URL. The function
+// returns an empty string if the repository is not in the local
+// filesystem.
+func RepositoryConfigPath(url string) string {
+	path, ok := FilesystemPath(url)
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf("%s/config", path)
+}
+
+// FilesystemPath returns the filesystem path of the repository URL
+// and a boolean success status.
+func FilesystemPath(url string) (string, bool) {
+	if strings.HasPrefix(url, "file://") {
+		return strings.TrimPrefix(url, "file://"), true
+	}
+	if strings.Contains(url, "://") {
+		return "", false
+	}
+	return url, true
+}
+
+// NewSettings creates settings with the default values.
+func NewSettings() *Settings {
+	return &Settings{
+		Repository:  DefaultRepository,
+		Zone:        DefaultZone,
+		ChunkSize:   DefaultChunkSize,
+		KeyBits:     DefaultKeyBits,
+		Compression: DefaultCompression,
+	}
+}
+
+// Apply applies the configuration file values to the settings.
+func (s *Settings) Apply(conf *Config) error {
+	if conf == nil {
+		return nil
+	}
+	if v, ok := conf.Get(KeyRepository); ok {
+		s.Repository = v
+	}
+	if v, ok := conf.Get(KeyZone); ok {
+		s.Zone = v
+	}
+	if v, ok := conf.Get(KeyAgentSocket); ok {
+		s.AgentSocket = v
+	}
+	if v, ok := conf.Get(KeyChunkSize); ok {
+		size, err := parseChunkSize(v)
+		if err != nil {
+			return fmt.Errorf("%s: %s: %s", conf.Path, KeyChunkSize, err)
+		}
+		s.ChunkSize = size
+	}
+	if v, ok := conf.Get(KeyKeyBits); ok {
+		bits, err := parseKeyBits(v)
+		if err != nil {
+			return fmt.Errorf("%s: %s: %s", conf.Path, KeyKeyBits, err)
+		}
+		s.KeyBits = bits
+	}
+	s.Excludes = append(s.Excludes, conf.GetAll(KeyExclude)...)
+	if v, ok := conf.Get(KeyCompression); ok {
+		b, err := ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("%s: %s: %s", conf.Path, KeyCompression, err)
+		}
+		s.Compression = b
+	}
+
+	for _, r := range []struct {
+		key string
+		val *int
+	}{
+		{KeyKeepLast, &s.Retention.KeepLast},
+		{KeyKeepDaily, &s.Retention.KeepDaily},
+		{KeyKeepWeekly, &s.Retention.KeepWeekly},
+		{KeyKeepMonthly, &s.Retention.KeepMonthly},
+	} {
+		if v, ok := conf.Get(r.key); ok {
+			n, err := parseCount(v)
+			if err != nil {
+				return fmt.Errorf("%s: %s: %s", conf.Path, r.key, err)
+			}
+			*r.val = n
+		}
+	}
+
+	return nil
+}
+
+// Validate verifies that the value is valid for the configuration
+// key. The values are parsed the same way as Apply parses them so
+// that invalid values are rejected before they are saved.
+func Validate(key, value string) error {
+	var err error
+	switch canonicalKey(key) {
+	case KeyChunkSize:
+		_, err = parseChunkSize(value)
+	case KeyKeyBits:
+		_, err = parseKeyBits(value)
+	case KeyCompression:
+		_, err = ParseBool(value)
+	case KeyKeepLast, KeyKeepDaily, KeyKeepWeekly, KeyKeepMonthly:
+		_, err = parseCount(value)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %s", key, err)
+	}
+	return nil
+}
+
+func parseChunkSize(v string) (int64, error) {
+	size, err := ParseSize(v)
+	if err != nil {
+		return 0, err
+	}
+	if size > MaxChunkSize {
+		return 0, fmt.Errorf("chunk size %d exceeds maximum %d", size,
+			MaxChunkSize)
+	}
+	return size, nil
+}
+
+func parseKeyBits(v string) (int, error) {
+	bits, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, err
+	}
+	if bits <= 0 {
+		return 0, fmt.Errorf("invalid key size %d", bits)
+	}
+	return bits, nil
+}
+
+func parseCount(v string) (int, error) {
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, err
+	}
+	if n < 0 {
+		return 0, fmt.Errorf("invalid count %d", n)
+	}
+	return n, nil
+}
+
+// ApplyEnv applies the environment variables to the settings.
+func (s *Settings) ApplyEnv() {
+	if v, ok := os.LookupEnv(EnvAgentSocket); ok {
+		s.AgentSocket = v
+	}
+	if v, ok := os.LookupEnv(EnvRepository); ok {
+		s.Repository = v
+	}
+	if v, ok := os.LookupEnv(EnvZone); ok {
+		s.Zone = v
+	}
+}
+
+// AgentSockets returns the agent socket paths. The AgentSocket
+// setting can list several sockets separated by the OS path list
+// separator.
+func (s *Settings) AgentSockets() []string {
+	var result []string
+	for _, path := range filepath.SplitList(s.AgentSocket) {
+		if len(path) > 0 {
+			result = append(result, path)
+		}
+	}
+	return result
+}
+
+// resolveRepository resolves the repository name into its URL and
+// credentials from the named repository definitions.
+func (s *Settings) resolveRepository(conf *Config) bool {
+	url, ok := conf.Get(fmt.Sprintf("repository.%s.url", s.Repository))
+	if !ok {
+		return false
+	}
+	s.URL = url
+	if v, ok := conf.Get(
+		fmt.Sprintf("repository.%s.credentials", s.Repository)); ok {
+		s.Credentials = v
+	}
+	return true
+}
+
+// Resolve resolves the repository URL with the named repository
+// definitions in the configuration files confs. If the repository
+// is not a named repository, it is used as the URL.
+func (s *Settings) Resolve(confs ...*Config) {
+	for i := len(confs) - 1; i >= 0; i-- {
+		if confs[i] != nil && s.resolveRepository(confs[i]) {
+			return
+		}
+	}
+	s.URL = s.Repository
+}
+
+// ReadCredentials reads the credentials that the Credentials
+// reference points to.
+func (s *Settings) ReadCredentials() (string, error) {
+	ref := s.Credentials
+	switch {
+	case len(ref) == 0:
+		return "", nil
+
+	case strings.HasPrefix(ref, "env:"):
+		v, ok := os.LookupEnv(ref[4:])
+		if !ok {
+			return "", fmt.Errorf("credentials variable %s not set", ref[4:])
+		}
+		return v, nil
+
+	case strings.HasPrefix(ref, "file:"):
+		data, err := ioutil.ReadFile(ref[5:])
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(string(data)), nil
+
+	default:
+		return "", fmt.Errorf("invalid credentials reference '%s'", ref)
+	}
+}
+
+// ParseSize parses the size value. The value can have k, M, or G
+// suffix, in either case, for kilobytes, megabytes, and gigabytes.
+func ParseSize(v string) (int64, error) {
+	var mul int64 = 1
+	if len(v) > 0 {
+		switch v[len(v)-1] {
+		case 'k', 'K':
+			mul = 1024
+		case 'm', 'M':
+			mul = 1024 * 1024
+		case 'g', 'G':
+			mul = 1024 * 1024 * 1024
+		}
+	}
+	if mul > 1 {
+		v = v[:len(v)-1]
+	}
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	if n <= 0 {
+		return 0, fmt.Errorf("invalid size %d", n)
+	}
+	if n > math.MaxInt64/mul {
+		return 0, fmt.Errorf("size %s too large", v)
+	}
+	return n * mul, nil
+}
+
+// ParseBool parses the boolean value.
+func ParseBool(v string) (bool, error) {
+	switch strings.ToLower(v) {
+	case "true", "yes", "on", "1":
+		return true, nil
+	case "false", "no", "off", "0":
+		return false, nil
+	default:
+		return false, fmt.Errorf("invalid boolean value '%s'", v)
+	}
+}
@@ -18,12 +18,16 @@ import (
 	"io"
 
 	"github.com/markkurossi/backup/lib/encoding"
+	"golang.org/x/crypto/argon2"
 	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/crypto/scrypt"
 )
 
+// The version 1 encrypted keys have the KDF parameters and the
+// version 2 keys have the public key and the creation time.
 const (
 	magic   = 0x42554944
-	version = byte(0)
+	version = byte(2)
 )
 
 // EncrAlg defines an encryption algorithm.
@@ -63,6 +67,12 @@ func (k KDFAlg) String() string {
 	case KDFPBKDF24096SHA256:
 		return "PBKDF2-4096-SHA256"
 
+	case KDFArgon2id:
+		return "Argon2id"
+
+	case KDFScrypt:
+		return "scrypt"
+
 	default:
 		return fmt.Sprintf("{KDFAlg %d}", k)
 	}
@@ -72,28 +82,176 @@ const (
 	// KDFPBKDF24096SHA256 defines key derivation function PBKDF with
 	// 4096 rounds of SHA-256.
 	KDFPBKDF24096SHA256 KDFAlg = 0
+	// KDFArgon2id defines the Argon2id key derivation function.
+	KDFArgon2id KDFAlg = 1
+	// KDFScrypt defines the scrypt key derivation function.
+	KDFScrypt KDFAlg = 2
+
+	// DefaultKDF defines the default key derivation function.
+	DefaultKDF = KDFArgon2id
 )
 
-// EncryptedKey implements an encrypted data blob.
+// ParseKDFAlg parses the key derivation function name.
+func ParseKDFAlg(name string) (KDFAlg, error) {
+	switch name {
+	case "argon2id":
+		return KDFArgon2id, nil
+	case "scrypt":
+		return KDFScrypt, nil
+	case "pbkdf2":
+		return KDFPBKDF24096SHA256, nil
+	default:
+		return 0, fmt.Errorf("unknown KDF algorithm '%s'", name)
+	}
+}
+
+// KDFParams define the parameters of the key derivation
+// functions. The parameters are not used with PBKDF2.
+type KDFParams struct {
+	// Argon2id passes and memory in KiB.
+	Time   uint32
+	Memory uint32
+	// Argon2id parallelism or scrypt parallelization p.
+	Threads uint32
+	// Scrypt cost N as log2(N) and block size r.
+	LogN      uint32
+	BlockSize uint32
+}
+
+// DefaultKDFParams returns the default parameters for the key
+// derivation function alg.
+func DefaultKDFParams(alg KDFAlg) KDFParams {
+	switch alg {
+	case KDFArgon2id:
+		return KDFParams{
+			Time:    3,
+			Memory:  64 * 1024,
+			Threads: 4,
+		}
+
+	case KDFScrypt:
+		return KDFParams{
+			Threads:   1,
+			LogN:      15,
+			BlockSize: 8,
+		}
+
+	default:
+		return KDFParams{}
+	}
+}
+
+// EncryptedKey implements an encrypted data blob. The version 0
+// encrypted keys do not have the KDFParams field and the version 0
+// and 1 keys do not have the PublicKey and Created fields.
 type EncryptedKey struct {
 	Magic     uint32
 	Version   byte
 	Name      string
 	Salt      []byte
 	KDFAlg    KDFAlg
+	KDFParams KDFParams
 	EncrAlg   EncrAlg
 	Encrypted []byte
+	// PublicKey is the marshaled public key of an encrypted private
+	// key.
+	PublicKey []byte
+	// Created is the key creation time in Unix nanoseconds.
+	Created int64
+}
+
+type encryptedKeyV1 struct {
+	Magic     uint32
+	Version   byte
+	Name      string
+	Salt      []byte
+	KDFAlg    KDFAlg
+	KDFParams KDFParams
+	EncrAlg   EncrAlg
+	Encrypted []byte
+}
+
+type encryptedKeyV0 struct {
+	Magic     uint32
+	Version   byte
+	Name      string
+	Salt      []byte
+	KDFAlg    KDFAlg
+	EncrAlg   EncrAlg
+	Encrypted []byte
+}
+
+// ParseEncryptedKey decodes the encrypted key blob.
+func ParseEncryptedKey(data []byte) (*EncryptedKey, error) {
+	if len(data) < 5 {
+		return nil, errors.New("Truncated ID key blob")
+	}
+	if binary.BigEndian.Uint32(data[:4]) != magic {
+		return nil, errors.New("Invalid ID key magic")
+	}
+	in := bytes.NewReader(data)
+	enc := new(EncryptedKey)
+
+	switch data[4] {
+	case 0:
+		v0 := new(encryptedKeyV0)
+		if err := encoding.Unmarshal(in, v0); err != nil {
+			return nil, err
+		}
+		enc.Magic = v0.Magic
+		enc.Version = v0.Version
+		enc.Name = v0.Name
+		enc.Salt = v0.Salt
+		enc.KDFAlg = v0.KDFAlg
+		enc.EncrAlg = v0.EncrAlg
+		enc.Encrypted = v0.Encrypted
+
+	case 1:
+		v1 := new(encryptedKeyV1)
+		if err := encoding.Unmarshal(in, v1); err != nil {
+			return nil, err
+		}
+		enc.Magic = v1.Magic
+		enc.Version = v1.Version
+		enc.Name = v1.Name
+		enc.Salt = v1.Salt
+		enc.KDFAlg = v1.KDFAlg
+		enc.KDFParams = v1.KDFParams
+		enc.EncrAlg = v1.EncrAlg
+		enc.Encrypted = v1.Encrypted
+
+	case version:
+		if err := encoding.Unmarshal(in, enc); err != nil {
+			return nil, err
+		}
+
+	default:
+		return nil, fmt.Errorf("invalid ID key version %d", data[4])
+	}
+	return enc, nil
 }
 
 // Encrypt encrypts the data with the encrAlg and passphrase.
 func Encrypt(data []byte, encrAlg EncrAlg, name string,
 	passphrase []byte, kdfAlg KDFAlg) ([]byte, error) {
 
+	enc, err := seal(data, encrAlg, name, passphrase, kdfAlg)
+	if err != nil {
+		return nil, err
+	}
+	return encoding.Marshal(enc)
+}
+
+// seal encrypts the data with the encrAlg and passphrase.
+func seal(data []byte, encrAlg EncrAlg, name string, passphrase []byte,
+	kdfAlg KDFAlg) (*EncryptedKey, error) {
+
 	salt := make([]byte, 16)
 	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
 		return nil, err
 	}
-	key, err := kdf(passphrase, salt, kdfAlg, encrAlg.KeyLen())
+	params := DefaultKDFParams(kdfAlg)
+	key, err := kdf(passphrase, salt, kdfAlg, params, encrAlg.KeyLen())
 	if err != nil {
 		return nil, err
 	}
@@ -108,33 +266,24 @@ func Encrypt(data []byte, encrAlg EncrAlg, name string,
 		Name:      name,
 		Salt:      salt,
 		KDFAlg:    kdfAlg,
+		KDFParams: params,
 		EncrAlg:   encrAlg,
 		Encrypted: encrypted,
 	}
 
-	return encoding.Marshal(enc)
+	return enc, nil
 }
 
 // Decrypt decrypts the ciphertext with the passphrase.
 func Decrypt(ciphertext, passphrase []byte) ([]byte, error) {
-	if len(ciphertext) < 5 {
-		return nil, errors.New("Truncated ID key blob")
-	}
-	if binary.BigEndian.Uint32(ciphertext[:4]) != magic {
-		return nil, errors.New("Invalid ID key magic")
-	}
-	if ciphertext[4] != version {
-		return nil, fmt.Errorf("invalid ID key version %d", ciphertext[4])
-	}
-
-	enc := new(EncryptedKey)
-	err := encoding.Unmarshal(bytes.NewReader(ciphertext), enc)
+	enc, err := ParseEncryptedKey(ciphertext)
 	if err != nil {
 		return nil, err
 	}
 
 	// Derive encryption key.
-	key, err := kdf(passphrase, enc.Salt, enc.KDFAlg, enc.EncrAlg.KeyLen())
+	key, err := kdf(passphrase, enc.Salt, enc.KDFAlg, enc.KDFParams,
+		enc.EncrAlg.KeyLen())
 	if err != nil {
 		return nil, err
 	}
@@ -142,11 +291,46 @@ func Decrypt(ciphertext, passphrase []byte) ([]byte, error) {
 	return decrypt(enc.Encrypted, enc.EncrAlg, key)
 }
 
-func kdf(passphrase, salt []byte, alg KDFAlg, keyLen int) ([]byte, error) {
+// The limits of the KDF parameters. The parameters are read from the
+// key files so they are limited to keep a crafted key file from
+// exhausting memory or CPU when the key is decrypted.
+const (
+	// maxKDFMemory is the maximum memory in KiB.
+	maxKDFMemory = 4 * 1024 * 1024
+	// maxKDFTime is the maximum number of Argon2id passes and scrypt
+	// parallelization.
+	maxKDFTime      = 64
+	maxKDFLogN      = 24
+	maxKDFBlockSize = 32
+)
+
+func kdf(passphrase, salt []byte, alg KDFAlg, params KDFParams,
+	keyLen int) ([]byte, error) {
+
 	switch alg {
 	case KDFPBKDF24096SHA256:
 		return pbkdf2.Key(passphrase, salt, 4096, keyLen, sha256.New), nil
 
+	case KDFArgon2id:
+		if params.Time == 0 || params.Time > maxKDFTime ||
+			params.Memory == 0 || params.Memory > maxKDFMemory ||
+			params.Threads == 0 || params.Threads > 255 {
+			return nil, fmt.Errorf("invalid %s parameters", alg)
+		}
+		return argon2.IDKey(passphrase, salt, params.Time, params.Memory,
+			uint8(params.Threads), uint32(keyLen)), nil
+
+	case KDFScrypt:
+		// The scrypt memory is 128*r*N bytes.
+		if params.LogN == 0 || params.LogN > maxKDFLogN ||
+			params.BlockSize == 0 || params.BlockSize > maxKDFBlockSize ||
+			params.Threads == 0 || params.Threads > maxKDFTime ||
+			uint64(params.BlockSize)<<params.LogN/8 > maxKDFMemory {
+			return nil, fmt.Errorf("invalid %s parameters", alg)
+		}
+		return scrypt.Key(passphrase, salt, 1<<params.LogN,
+			int(params.BlockSize), int(params.Threads), keyLen)
+
 	default:
 		return nil, fmt.Errorf("unknown KDF algorithm %s", alg)
 	}
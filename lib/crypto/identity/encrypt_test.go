@@ -0,0 +1,105 @@
+//
+// Copyright (c) 2026 Markku Rossi
+//
+// All rights reserved.
+//
+
+package identity
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/markkurossi/backup/lib/encoding"
+)
+
+func TestEncryptKDF(t *testing.T) {
+	data := []byte("Hello, world!")
+	passphrase := []byte("passphrase")
+
+	for _, alg := range []KDFAlg{
+		KDFPBKDF24096SHA256, KDFArgon2id, KDFScrypt,
+	} {
+		encrypted, err := Encrypt(data, EncrAES128GCM, "key", passphrase, alg)
+		if err != nil {
+			t.Fatalf("%s: Encrypt failed: %v", alg, err)
+		}
+		enc, err := ParseEncryptedKey(encrypted)
+		if err != nil {
+			t.Fatalf("%s: ParseEncryptedKey failed: %v", alg, err)
+		}
+		if enc.Version != version || enc.KDFAlg != alg ||
+			enc.KDFParams != DefaultKDFParams(alg) {
+			t.Errorf("%s: unexpected encrypted key: %+v", alg, enc)
+		}
+		decrypted, err := Decrypt(encrypted, passphrase)
+		if err != nil {
+			t.Fatalf("%s: Decrypt failed: %v", alg, err)
+		}
+		if !bytes.Equal(data, decrypted) {
+			t.Errorf("%s: decrypted data mismatch", alg)
+		}
+		if _, err := Decrypt(encrypted, []byte("wrong")); err == nil {
+			t.Errorf("%s: decrypted with wrong passphrase", alg)
+		}
+	}
+}
+
+func TestKDFLimits(t *testing.T) {
+	tests := []struct {
+		alg    KDFAlg
+		params KDFParams
+	}{
+		{KDFArgon2id, KDFParams{Time: 3, Memory: 8 * 1024 * 1024, Threads: 4}},
+		{KDFArgon2id, KDFParams{Time: 65, Memory: 64 * 1024, Threads: 4}},
+		{KDFScrypt, KDFParams{Threads: 1, LogN: 25, BlockSize: 8}},
+		{KDFScrypt, KDFParams{Threads: 1, LogN: 15, BlockSize: 33}},
+		{KDFScrypt, KDFParams{Threads: 1, LogN: 24, BlockSize: 32}},
+		{KDFScrypt, KDFParams{Threads: 65, LogN: 15, BlockSize: 8}},
+	}
+	for _, test := range tests {
+		_, err := kdf([]byte("passphrase"), []byte("salt"), test.alg,
+			test.params, 16)
+		if err == nil {
+			t.Errorf("%s: accepted parameters %+v", test.alg, test.params)
+		}
+	}
+}
+
+func TestDecryptV0(t *testing.T) {
+	data := []byte("Hello, world!")
+	passphrase := []byte("passphrase")
+	salt := []byte("0123456789abcdef")
+
+	key, err := kdf(passphrase, salt, KDFPBKDF24096SHA256, KDFParams{},
+		EncrAES128GCM.KeyLen())
+	if err != nil {
+		t.Fatal(err)
+	}
+	encrypted, err := encrypt(data, EncrAES128GCM, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v0, err := encoding.Marshal(&encryptedKeyV0{
+		Magic:     magic,
+		Version:   0,
+		Name:      "key",
+		Salt:      salt,
+		KDFAlg:    KDFPBKDF24096SHA256,
+		EncrAlg:   EncrAES128GCM,
+		Encrypted: encrypted,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	decrypted, err := Decrypt(v0, passphrase)
+	if err != nil {
+		t.Fatalf("Decrypt failed: %v", err)
+	}
+	if !bytes.Equal(data, decrypted) {
+		t.Errorf("decrypted data mismatch")
+	}
+	if _, err := GetNull(); err != nil {
+		t.Errorf("GetNull failed: %v", err)
+	}
+}
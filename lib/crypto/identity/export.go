@@ -0,0 +1,94 @@
+//
+// Copyright (c) 2026 Markku Rossi
+//
+// All rights reserved.
+//
+
+package identity
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Public key export formats.
+const (
+	PEMType    = "BACKUP PUBLIC KEY"
+	TextPrefix = "backup-key"
+)
+
+// MarshalPublicKeyPEM encodes the public key as a PEM block. The
+// block headers contain the key name and ID.
+func MarshalPublicKeyPEM(key PublicKey) ([]byte, error) {
+	data, err := key.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{
+		Type: PEMType,
+		Headers: map[string]string{
+			"Name": key.Name(),
+			"ID":   key.ID(),
+		},
+		Bytes: data,
+	}), nil
+}
+
+// MarshalPublicKeyText encodes the public key as a single text line
+// "backup-key base64 id name".
+func MarshalPublicKeyText(key PublicKey) ([]byte, error) {
+	data, err := key.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	return []byte(fmt.Sprintf("%s %s %s %s\n", TextPrefix,
+		base64.StdEncoding.EncodeToString(data), key.ID(), key.Name())), nil
+}
+
+// ParsePublicKey decodes the public key from the PEM or text
+// encoding. If the encoding specifies the key ID, the function
+// verifies that it matches the decoded key.
+func ParsePublicKey(data []byte) (PublicKey, error) {
+	var keyData []byte
+	var id string
+
+	data = bytes.TrimSpace(data)
+	if bytes.HasPrefix(data, []byte("-----BEGIN ")) {
+		block, _ := pem.Decode(data)
+		if block == nil {
+			return nil, errors.New("invalid PEM data")
+		}
+		if block.Type != PEMType {
+			return nil, fmt.Errorf("unexpected PEM block type '%s'",
+				block.Type)
+		}
+		keyData = block.Bytes
+		id = block.Headers["ID"]
+	} else {
+		fields := strings.Fields(string(data))
+		if len(fields) < 2 || fields[0] != TextPrefix {
+			return nil, errors.New("invalid public key encoding")
+		}
+		var err error
+		keyData, err = base64.StdEncoding.DecodeString(fields[1])
+		if err != nil {
+			return nil, err
+		}
+		if len(fields) > 2 {
+			id = fields[2]
+		}
+	}
+	key, err := UnmarshalPublicKey(keyData)
+	if err != nil {
+		return nil, err
+	}
+	if len(id) > 0 && id != key.ID() {
+		return nil, fmt.Errorf("public key ID mismatch: %s vs. %s",
+			id, key.ID())
+	}
+	return key, nil
+}
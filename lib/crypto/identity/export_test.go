@@ -0,0 +1,56 @@
+//
+// Copyright (c) 2026 Markku Rossi
+//
+// All rights reserved.
+//
+
+package identity
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestExport(t *testing.T) {
+	key, err := NewEd25519Key("Test Key")
+	if err != nil {
+		t.Fatalf("Failed to generate ed25519 key: %v", err)
+	}
+	pub := key.PublicKey()
+
+	for _, marshal := range []func(PublicKey) ([]byte, error){
+		MarshalPublicKeyPEM, MarshalPublicKeyText,
+	} {
+		data, err := marshal(pub)
+		if err != nil {
+			t.Fatalf("Failed to marshal public key: %v", err)
+		}
+		parsed, err := ParsePublicKey(data)
+		if err != nil {
+			t.Fatalf("Failed to parse public key: %v\n%s", err, data)
+		}
+		if parsed.ID() != pub.ID() {
+			t.Errorf("Key ID mismatch: %s vs. %s", parsed.ID(), pub.ID())
+		}
+		if parsed.Name() != pub.Name() {
+			t.Errorf("Key name mismatch: %s vs. %s", parsed.Name(),
+				pub.Name())
+		}
+	}
+
+	other, err := NewEd25519Key("Other Key")
+	if err != nil {
+		t.Fatalf("Failed to generate ed25519 key: %v", err)
+	}
+	data, err := MarshalPublicKeyText(pub)
+	if err != nil {
+		t.Fatalf("Failed to marshal public key: %v", err)
+	}
+	data = bytes.Replace(data, []byte(pub.ID()), []byte(other.ID()), 1)
+	if _, err := ParsePublicKey(data); err == nil {
+		t.Errorf("Public key with wrong ID accepted")
+	}
+	if _, err := ParsePublicKey([]byte("Hello, world!")); err == nil {
+		t.Errorf("Invalid public key accepted")
+	}
+}
@@ -8,7 +8,10 @@ package identity
 
 import (
 	"bytes"
+	"encoding/base64"
+	"encoding/hex"
 	"fmt"
+	"strings"
 
 	"github.com/markkurossi/backup/lib/encoding"
 )
@@ -35,6 +38,17 @@ type PublicKey interface {
 	Encrypt(msg []byte) ([]byte, error)
 }
 
+// Signer implements a private key that can sign messages.
+type Signer interface {
+	Sign(msg []byte) ([]byte, error)
+}
+
+// Verifier implements a public key that can verify message
+// signatures.
+type Verifier interface {
+	Verify(msg, signature []byte) error
+}
+
 // KeyType defines a key type.
 type KeyType int
 
@@ -46,6 +60,15 @@ func (t KeyType) String() string {
 	case KeyRSAPublicKey:
 		return "rsa-public-key"
 
+	case KeyEd25519PrivateKey:
+		return "ed25519-private-key"
+
+	case KeyEd25519PublicKey:
+		return "ed25519-public-key"
+
+	case KeyRecovery:
+		return "recovery-key"
+
 	default:
 		return fmt.Sprintf("{KeyType %d}", t)
 	}
@@ -55,6 +78,9 @@ func (t KeyType) String() string {
 const (
 	KeyRSAPrivateKey KeyType = iota
 	KeyRSAPublicKey
+	KeyEd25519PrivateKey
+	KeyEd25519PublicKey
+	KeyRecovery
 )
 
 // KeyData implements a keypair.
@@ -78,6 +104,12 @@ func Unmarshal(data []byte) (Key, error) {
 	case KeyRSAPublicKey:
 		return UnmarshalRSAPublicKey(keyData)
 
+	case KeyEd25519PrivateKey:
+		return UnmarshalEd25519PrivateKey(keyData)
+
+	case KeyEd25519PublicKey:
+		return UnmarshalEd25519PublicKey(keyData)
+
 	default:
 		return nil, fmt.Errorf("invalid key type %s", keyData.Type)
 	}
@@ -108,3 +140,35 @@ func UnmarshalPublicKey(data []byte) (PublicKey, error) {
 	}
 	return private, nil
 }
+
+// Rename returns a copy of the key with the new name.
+func Rename(key Key, name string) (Key, error) {
+	data, err := key.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	keyData := new(KeyData)
+	if err := encoding.Unmarshal(bytes.NewReader(data), keyData); err != nil {
+		return nil, err
+	}
+	keyData.Name = name
+	data, err = encoding.Marshal(keyData)
+	if err != nil {
+		return nil, err
+	}
+	return Unmarshal(data)
+}
+
+// Fingerprint returns the key fingerprint of the key ID. The
+// fingerprint is the base64 encoded SHA-256 hash of the public key,
+// in the same format as OpenSSH uses for its key fingerprints.
+func Fingerprint(id string) string {
+	if !strings.HasPrefix(id, "sha256:") {
+		return id
+	}
+	sum, err := hex.DecodeString(id[7:])
+	if err != nil {
+		return id
+	}
+	return "SHA256:" + base64.RawStdEncoding.EncodeToString(sum)
+}
@@ -0,0 +1,241 @@
+//
+// Copyright (c) 2026 Markku Rossi
+//
+// All rights reserved.
+//
+
+package identity
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ed25519"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"fmt"
+
+	"github.com/markkurossi/backup/lib/encoding"
+)
+
+// The Curve25519 identity keys use Ed25519 for signing and X25519
+// for encryption. The X25519 private key is derived from the Ed25519
+// seed so the private key data is the 32-byte seed. The public key
+// data is the Ed25519 public key followed by the X25519 public key.
+const (
+	ed25519PublicKeySize = ed25519.PublicKeySize + 32
+	ed25519KeySize       = 256
+)
+
+type ed25519PrivateKey struct {
+	name    string
+	private ed25519.PrivateKey
+	x25519  *ecdh.PrivateKey
+}
+
+func newEd25519PrivateKey(name string, seed []byte) (*ed25519PrivateKey,
+	error) {
+
+	if len(seed) != ed25519.SeedSize {
+		return nil, fmt.Errorf("invalid ed25519 seed size %d", len(seed))
+	}
+	// Derive the X25519 scalar like the Ed25519 signing scalar is
+	// derived from the seed. The X25519 function clamps the scalar.
+	digest := sha512.Sum512(seed)
+	x, err := ecdh.X25519().NewPrivateKey(digest[:32])
+	if err != nil {
+		return nil, err
+	}
+	return &ed25519PrivateKey{
+		name:    name,
+		private: ed25519.NewKeyFromSeed(seed),
+		x25519:  x,
+	}, nil
+}
+
+func (key *ed25519PrivateKey) Name() string {
+	return key.name
+}
+
+func (key *ed25519PrivateKey) Type() KeyType {
+	return KeyEd25519PrivateKey
+}
+
+func (key *ed25519PrivateKey) Size() int {
+	return ed25519KeySize
+}
+
+func (key *ed25519PrivateKey) ID() string {
+	return key.public().ID()
+}
+
+func (key *ed25519PrivateKey) Marshal() ([]byte, error) {
+	keyData := &KeyData{
+		Name: key.name,
+		Type: KeyEd25519PrivateKey,
+		Data: key.private.Seed(),
+	}
+	return encoding.Marshal(keyData)
+}
+
+func (key *ed25519PrivateKey) Decrypt(ciphertext []byte) ([]byte, error) {
+	if len(ciphertext) < 32 {
+		return nil, fmt.Errorf("truncated ciphertext")
+	}
+	ephemeral, err := ecdh.X25519().NewPublicKey(ciphertext[:32])
+	if err != nil {
+		return nil, err
+	}
+	shared, err := key.x25519.ECDH(ephemeral)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := x25519AEAD(shared, ephemeral, key.x25519.PublicKey())
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	return aead.Open(nil, nonce, ciphertext[32:], nil)
+}
+
+// Sign signs the message with the Ed25519 key.
+func (key *ed25519PrivateKey) Sign(msg []byte) ([]byte, error) {
+	return ed25519.Sign(key.private, msg), nil
+}
+
+func (key *ed25519PrivateKey) PublicKey() PublicKey {
+	return key.public()
+}
+
+func (key *ed25519PrivateKey) public() *ed25519PublicKey {
+	return &ed25519PublicKey{
+		name:   key.name,
+		public: key.private.Public().(ed25519.PublicKey),
+		x25519: key.x25519.PublicKey(),
+	}
+}
+
+type ed25519PublicKey struct {
+	name   string
+	public ed25519.PublicKey
+	x25519 *ecdh.PublicKey
+}
+
+func (key *ed25519PublicKey) Name() string {
+	return key.name
+}
+
+func (key *ed25519PublicKey) Type() KeyType {
+	return KeyEd25519PublicKey
+}
+
+func (key *ed25519PublicKey) Size() int {
+	return ed25519KeySize
+}
+
+func (key *ed25519PublicKey) ID() string {
+	sum := sha256.Sum256(key.data())
+	return fmt.Sprintf("sha256:%x", sum[:])
+}
+
+func (key *ed25519PublicKey) data() []byte {
+	var data []byte
+	data = append(data, key.public...)
+	return append(data, key.x25519.Bytes()...)
+}
+
+func (key *ed25519PublicKey) Marshal() ([]byte, error) {
+	keyData := &KeyData{
+		Name: key.name,
+		Type: KeyEd25519PublicKey,
+		Data: key.data(),
+	}
+	return encoding.Marshal(keyData)
+}
+
+// Encrypt encrypts the message with an ephemeral X25519 key. The
+// ciphertext is the ephemeral public key followed by the AEAD
+// encrypted message.
+func (key *ed25519PublicKey) Encrypt(msg []byte) ([]byte, error) {
+	ephemeral, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	shared, err := ephemeral.ECDH(key.x25519)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := x25519AEAD(shared, ephemeral.PublicKey(), key.x25519)
+	if err != nil {
+		return nil, err
+	}
+	// The encryption key is unique for each message so the nonce
+	// can be fixed.
+	nonce := make([]byte, aead.NonceSize())
+	result := ephemeral.PublicKey().Bytes()
+	return aead.Seal(result, nonce, msg, nil), nil
+}
+
+// Verify verifies the message signature with the Ed25519 key.
+func (key *ed25519PublicKey) Verify(msg, signature []byte) error {
+	if !ed25519.Verify(key.public, msg, signature) {
+		return fmt.Errorf("ed25519 signature verification failed")
+	}
+	return nil
+}
+
+// x25519AEAD creates the AEAD cipher for the X25519 shared secret.
+// The encryption key is derived with HKDF-SHA256 from the shared
+// secret and from the ephemeral and recipient public keys.
+func x25519AEAD(shared []byte, ephemeral, recipient *ecdh.PublicKey) (
+	cipher.AEAD, error) {
+
+	var salt []byte
+	salt = append(salt, ephemeral.Bytes()...)
+	salt = append(salt, recipient.Bytes()...)
+
+	key, err := hkdf.Key(sha256.New, shared, salt, string(label), 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// NewEd25519Key creates a new Curve25519 keypair.
+func NewEd25519Key(name string) (PrivateKey, error) {
+	seed := make([]byte, ed25519.SeedSize)
+	if _, err := rand.Read(seed); err != nil {
+		return nil, err
+	}
+	return newEd25519PrivateKey(name, seed)
+}
+
+// UnmarshalEd25519PrivateKey decodes Curve25519 private key from the
+// data.
+func UnmarshalEd25519PrivateKey(data *KeyData) (PrivateKey, error) {
+	return newEd25519PrivateKey(data.Name, data.Data)
+}
+
+// UnmarshalEd25519PublicKey decodes Curve25519 public key from the
+// data.
+func UnmarshalEd25519PublicKey(data *KeyData) (PublicKey, error) {
+	if len(data.Data) != ed25519PublicKeySize {
+		return nil, fmt.Errorf("invalid ed25519 public key size %d",
+			len(data.Data))
+	}
+	x, err := ecdh.X25519().NewPublicKey(data.Data[ed25519.PublicKeySize:])
+	if err != nil {
+		return nil, err
+	}
+	return &ed25519PublicKey{
+		name:   data.Name,
+		public: ed25519.PublicKey(data.Data[:ed25519.PublicKeySize]),
+		x25519: x,
+	}, nil
+}
@@ -0,0 +1,85 @@
+//
+// Copyright (c) 2026 Markku Rossi
+//
+// All rights reserved.
+//
+
+package identity
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestEd25519(t *testing.T) {
+	key, err := NewEd25519Key("Test Key")
+	if err != nil {
+		t.Fatalf("Failed to generate ed25519 key: %v", err)
+	}
+	data, err := key.Marshal()
+	if err != nil {
+		t.Fatalf("Failed to marshal ed25519 key: %v", err)
+	}
+	key2, err := UnmarshalPrivateKey(data)
+	if err != nil {
+		t.Fatalf("Failed to unmarshal ed25519 key: %v", err)
+	}
+	if key.ID() != key2.ID() {
+		t.Fatalf("Key ID mismatch: %s vs. %s", key.ID(), key2.ID())
+	}
+
+	data, err = key.PublicKey().Marshal()
+	if err != nil {
+		t.Fatalf("Failed to marshal ed25519 public key: %v", err)
+	}
+	pub, err := UnmarshalPublicKey(data)
+	if err != nil {
+		t.Fatalf("Failed to unmarshal ed25519 public key: %v", err)
+	}
+	if pub.ID() != key.ID() {
+		t.Fatalf("Public key ID mismatch: %s vs. %s", pub.ID(), key.ID())
+	}
+	if _, err := UnmarshalPrivateKey(data); err == nil {
+		t.Fatalf("Public key unmarshalled as private key")
+	}
+
+	msg := []byte("Hello, world!")
+	encrypted, err := pub.Encrypt(msg)
+	if err != nil {
+		t.Fatalf("Failed to encrypt with public key: %v", err)
+	}
+	decrypted, err := key2.Decrypt(encrypted)
+	if err != nil {
+		t.Fatalf("Failed to decrypt with private key: %v", err)
+	}
+	if !bytes.Equal(msg, decrypted) {
+		t.Fatalf("Decrypted data does not match original")
+	}
+	encrypted[len(encrypted)-1] ^= 0x01
+	if _, err := key2.Decrypt(encrypted); err == nil {
+		t.Fatalf("Modified ciphertext decrypted")
+	}
+
+	other, err := NewEd25519Key("Other Key")
+	if err != nil {
+		t.Fatalf("Failed to generate ed25519 key: %v", err)
+	}
+	encrypted, err = other.PublicKey().Encrypt(msg)
+	if err != nil {
+		t.Fatalf("Failed to encrypt with public key: %v", err)
+	}
+	if _, err := key.Decrypt(encrypted); err == nil {
+		t.Fatalf("Decrypted with wrong key")
+	}
+
+	signature, err := key2.(Signer).Sign(msg)
+	if err != nil {
+		t.Fatalf("Failed to sign message: %v", err)
+	}
+	if err := pub.(Verifier).Verify(msg, signature); err != nil {
+		t.Fatalf("Failed to verify signature: %v", err)
+	}
+	if err := pub.(Verifier).Verify([]byte("Hello"), signature); err == nil {
+		t.Fatalf("Invalid signature verified")
+	}
+}
@@ -7,6 +7,7 @@
 package identity
 
 import (
+	"crypto"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/sha256"
@@ -55,6 +56,13 @@ func (key *rsaPrivateKey) Decrypt(ciphertext []byte) ([]byte, error) {
 		label)
 }
 
+// Sign signs the message with RSASSA-PKCS1-v1_5 and SHA-256.
+func (key *rsaPrivateKey) Sign(msg []byte) ([]byte, error) {
+	digest := sha256.Sum256(msg)
+	return rsa.SignPKCS1v15(rand.Reader, key.private, crypto.SHA256,
+		digest[:])
+}
+
 func (key *rsaPrivateKey) PublicKey() PublicKey {
 	return &rsaPublicKey{
 		name:   key.name,
@@ -96,6 +104,13 @@ func (key *rsaPublicKey) Encrypt(msg []byte) ([]byte, error) {
 	return rsa.EncryptOAEP(sha256.New(), rand.Reader, key.public, msg, label)
 }
 
+// Verify verifies the RSASSA-PKCS1-v1_5 SHA-256 message signature.
+func (key *rsaPublicKey) Verify(msg, signature []byte) error {
+	digest := sha256.Sum256(msg)
+	return rsa.VerifyPKCS1v15(key.public, crypto.SHA256, digest[:],
+		signature)
+}
+
 func keyID(key *rsa.PublicKey) string {
 	data := x509.MarshalPKCS1PublicKey(key)
 	sum := sha256.Sum256(data)
@@ -0,0 +1,62 @@
+//
+// Copyright (c) 2026 Markku Rossi
+//
+// All rights reserved.
+//
+
+package identity
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// ErrPassphraseMissing is returned when an encrypted OpenSSH private
+// key is parsed without a passphrase.
+var ErrPassphraseMissing = errors.New("passphrase required")
+
+// ParseOpenSSHKey parses the OpenSSH private key file data and
+// converts it to an identity private key. The passphrase is used if
+// the key file is passphrase-protected. The function returns
+// ErrPassphraseMissing if the key is passphrase-protected and
+// passphrase is empty. The RSA and Ed25519 keys are supported.
+func ParseOpenSSHKey(name string, data, passphrase []byte) (PrivateKey,
+	error) {
+
+	var raw interface{}
+	var err error
+
+	if len(passphrase) > 0 {
+		raw, err = ssh.ParseRawPrivateKeyWithPassphrase(data, passphrase)
+	} else {
+		raw, err = ssh.ParseRawPrivateKey(data)
+	}
+	if err != nil {
+		var missing *ssh.PassphraseMissingError
+		if errors.As(err, &missing) {
+			return nil, ErrPassphraseMissing
+		}
+		return nil, err
+	}
+
+	switch key := raw.(type) {
+	case *rsa.PrivateKey:
+		return &rsaPrivateKey{
+			name:    name,
+			private: key,
+		}, nil
+
+	case *ed25519.PrivateKey:
+		return newEd25519PrivateKey(name, key.Seed())
+
+	case ed25519.PrivateKey:
+		return newEd25519PrivateKey(name, key.Seed())
+
+	default:
+		return nil, fmt.Errorf("unsupported OpenSSH key type %T", raw)
+	}
+}
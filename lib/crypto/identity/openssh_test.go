@@ -0,0 +1,106 @@
+//
+// Copyright (c) 2026 Markku Rossi
+//
+// All rights reserved.
+//
+
+package identity
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/pem"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func TestOpenSSH(t *testing.T) {
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate ed25519 key: %v", err)
+	}
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatalf("Failed to generate RSA key: %v", err)
+	}
+	edExpected, err := newEd25519PrivateKey("", edKey.Seed())
+	if err != nil {
+		t.Fatalf("Failed to create ed25519 key: %v", err)
+	}
+	passphrase := []byte("Hello, world!")
+
+	tests := []struct {
+		key      interface{}
+		keyType  KeyType
+		expected PrivateKey
+	}{
+		{
+			key:      edKey,
+			keyType:  KeyEd25519PrivateKey,
+			expected: edExpected,
+		},
+		{
+			key:     rsaKey,
+			keyType: KeyRSAPrivateKey,
+			expected: &rsaPrivateKey{
+				private: rsaKey,
+			},
+		},
+	}
+	for idx, test := range tests {
+		block, err := ssh.MarshalPrivateKey(test.key, "test")
+		if err != nil {
+			t.Fatalf("%d: MarshalPrivateKey failed: %v", idx, err)
+		}
+		key, err := ParseOpenSSHKey("test", pem.EncodeToMemory(block), nil)
+		if err != nil {
+			t.Fatalf("%d: ParseOpenSSHKey failed: %v", idx, err)
+		}
+		if key.Type() != test.keyType {
+			t.Errorf("%d: got key type %s, expected %s", idx, key.Type(),
+				test.keyType)
+		}
+		if key.ID() != test.expected.ID() {
+			t.Errorf("%d: key ID mismatch", idx)
+		}
+
+		block, err = ssh.MarshalPrivateKeyWithPassphrase(test.key, "test",
+			passphrase)
+		if err != nil {
+			t.Fatalf("%d: MarshalPrivateKeyWithPassphrase failed: %v",
+				idx, err)
+		}
+		data := pem.EncodeToMemory(block)
+		_, err = ParseOpenSSHKey("test", data, nil)
+		if err != ErrPassphraseMissing {
+			t.Errorf("%d: expected ErrPassphraseMissing, got %v", idx, err)
+		}
+		_, err = ParseOpenSSHKey("test", data, []byte("wrong"))
+		if err == nil {
+			t.Errorf("%d: wrong passphrase accepted", idx)
+		}
+		key, err = ParseOpenSSHKey("test", data, passphrase)
+		if err != nil {
+			t.Fatalf("%d: ParseOpenSSHKey failed: %v", idx, err)
+		}
+		if key.ID() != test.expected.ID() {
+			t.Errorf("%d: key ID mismatch", idx)
+		}
+
+		msg := []byte("Hello, world!")
+		encrypted, err := key.PublicKey().Encrypt(msg)
+		if err != nil {
+			t.Fatalf("%d: Encrypt failed: %v", idx, err)
+		}
+		decrypted, err := test.expected.Decrypt(encrypted)
+		if err != nil {
+			t.Fatalf("%d: Decrypt failed: %v", idx, err)
+		}
+		if !bytes.Equal(msg, decrypted) {
+			t.Errorf("%d: decrypted data mismatch", idx)
+		}
+	}
+}
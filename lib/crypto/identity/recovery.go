@@ -0,0 +1,171 @@
+//
+// Copyright (c) 2026 Markku Rossi
+//
+// All rights reserved.
+//
+
+package identity
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// RecoveryIDPrefix defines the ID prefix of the recovery keys.
+const RecoveryIDPrefix = "recovery:"
+
+const (
+	recoveryKeyLen = 20
+	recoveryGroup  = 4
+)
+
+// The recovery keys are symmetric keys that are shown to the user
+// once and entered interactively when no other identity can open the
+// zone. The zone secret is encrypted with AES-256-GCM under a key
+// that is derived from the recovery key with HKDF-SHA256. The
+// recovery key has 160 bits of entropy so a memory-hard KDF is not
+// needed. The key ID is derived from the recovery key so the zone
+// identity can be located without storing the key.
+type recoveryKey struct {
+	secret []byte
+}
+
+// NewRecoveryKey creates a new random recovery key. The function
+// returns the key and its text encoding for the user.
+func NewRecoveryKey() (PrivateKey, string, error) {
+	secret := make([]byte, recoveryKeyLen)
+	if _, err := io.ReadFull(rand.Reader, secret); err != nil {
+		return nil, "", err
+	}
+	return &recoveryKey{
+		secret: secret,
+	}, formatRecoveryKey(secret), nil
+}
+
+var recoveryEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+func formatRecoveryKey(secret []byte) string {
+	text := recoveryEncoding.EncodeToString(secret)
+	var groups []string
+	for len(text) > 0 {
+		n := recoveryGroup
+		if n > len(text) {
+			n = len(text)
+		}
+		groups = append(groups, text[:n])
+		text = text[n:]
+	}
+	return strings.Join(groups, "-")
+}
+
+// ParseRecoveryKey parses the recovery key text. The parser ignores
+// letter case, whitespace, and dashes, and it accepts the digits 0,
+// 1, and 8 for the letters O, I, and B.
+func ParseRecoveryKey(text string) (PrivateKey, error) {
+	var sb strings.Builder
+	for _, r := range strings.ToUpper(text) {
+		switch r {
+		case ' ', '\t', '\n', '\r', '-':
+		case '0':
+			sb.WriteRune('O')
+		case '1':
+			sb.WriteRune('I')
+		case '8':
+			sb.WriteRune('B')
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	if sb.Len() != recoveryEncoding.EncodedLen(recoveryKeyLen) {
+		return nil, errors.New("invalid recovery key length")
+	}
+	secret, err := recoveryEncoding.DecodeString(sb.String())
+	if err != nil {
+		return nil, errors.New("invalid recovery key")
+	}
+	return &recoveryKey{
+		secret: secret,
+	}, nil
+}
+
+func (key *recoveryKey) derive(info string) []byte {
+	k, err := hkdf.Key(sha256.New, key.secret, nil, info, 32)
+	if err != nil {
+		panic(err)
+	}
+	return k
+}
+
+func (key *recoveryKey) aead() (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key.derive(string(label)))
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func (key *recoveryKey) Name() string {
+	return "recovery key"
+}
+
+func (key *recoveryKey) Type() KeyType {
+	return KeyRecovery
+}
+
+func (key *recoveryKey) Size() int {
+	return recoveryKeyLen * 8
+}
+
+func (key *recoveryKey) ID() string {
+	return fmt.Sprintf("%s%x", RecoveryIDPrefix,
+		key.derive("Backup Recovery Key ID")[:16])
+}
+
+// Marshal returns an error because the recovery keys are never
+// stored.
+func (key *recoveryKey) Marshal() ([]byte, error) {
+	return nil, errors.New("recovery keys can't be marshaled")
+}
+
+func (key *recoveryKey) Encrypt(msg []byte) ([]byte, error) {
+	aead, err := key.aead()
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, msg, nil), nil
+}
+
+func (key *recoveryKey) Decrypt(ciphertext []byte) ([]byte, error) {
+	aead, err := key.aead()
+	if err != nil {
+		return nil, err
+	}
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, errors.New("truncated ciphertext")
+	}
+	n := aead.NonceSize()
+	return aead.Open(nil, ciphertext[:n], ciphertext[n:], nil)
+}
+
+// PublicKey returns the recovery key itself since the recovery keys
+// are symmetric.
+func (key *recoveryKey) PublicKey() PublicKey {
+	return key
+}
+
+// IsRecoveryID tests if the key ID is a recovery key ID.
+func IsRecoveryID(id string) bool {
+	return strings.HasPrefix(id, RecoveryIDPrefix)
+}
@@ -0,0 +1,67 @@
+//
+// Copyright (c) 2026 Markku Rossi
+//
+// All rights reserved.
+//
+
+package identity
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRecoveryKey(t *testing.T) {
+	key, text, err := NewRecoveryKey()
+	if err != nil {
+		t.Fatalf("NewRecoveryKey failed: %v", err)
+	}
+	if !IsRecoveryID(key.ID()) {
+		t.Errorf("invalid recovery key ID %s", key.ID())
+	}
+	if len(strings.Split(text, "-")) != 8 {
+		t.Errorf("unexpected recovery key format: %s", text)
+	}
+
+	msg := []byte("Hello, world!")
+	encrypted, err := key.PublicKey().Encrypt(msg)
+	if err != nil {
+		t.Fatalf("Encrypt failed: %v", err)
+	}
+
+	for _, input := range []string{
+		text,
+		strings.ToLower(text),
+		strings.ReplaceAll(text, "-", " "),
+		strings.NewReplacer("O", "0", "I", "1", "B", "8").Replace(text),
+	} {
+		parsed, err := ParseRecoveryKey(input)
+		if err != nil {
+			t.Fatalf("ParseRecoveryKey(%q) failed: %v", input, err)
+		}
+		if parsed.ID() != key.ID() {
+			t.Errorf("ParseRecoveryKey(%q): ID mismatch", input)
+		}
+		decrypted, err := parsed.Decrypt(encrypted)
+		if err != nil {
+			t.Fatalf("Decrypt failed: %v", err)
+		}
+		if !bytes.Equal(msg, decrypted) {
+			t.Errorf("decrypted data mismatch")
+		}
+	}
+
+	for _, input := range []string{"", "ABCD-EFGH", text + "A", "!" + text} {
+		if _, err := ParseRecoveryKey(input); err == nil {
+			t.Errorf("ParseRecoveryKey(%q) succeeded", input)
+		}
+	}
+	other, _, err := NewRecoveryKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := other.Decrypt(encrypted); err == nil {
+		t.Errorf("Decrypted with wrong recovery key")
+	}
+}
@@ -9,12 +9,13 @@
 package identity
 
 import (
-	"bytes"
 	"fmt"
 	"io/ioutil"
 	"log"
 	"os"
 	"os/user"
+	"strings"
+	"time"
 
 	"github.com/markkurossi/backup/lib/encoding"
 )
@@ -23,12 +24,15 @@ import (
 func NewStorage(user *user.User) *Storage {
 	return &Storage{
 		Dir: fmt.Sprintf("%s/.backup.d/identities", user.HomeDir),
+		KDF: DefaultKDF,
 	}
 }
 
 // Storage implements an identity storage.
 type Storage struct {
 	Dir string
+	// KDF specifies the key derivation function for the saved keys.
+	KDF KDFAlg
 }
 
 // Open opens the storage.
@@ -40,6 +44,13 @@ func (s *Storage) Open() error {
 type KeyInfo struct {
 	ID   string
 	Name string
+	// PublicKey is the public key of the identity key. It is nil for
+	// the keys that were saved with older versions.
+	PublicKey PublicKey
+	// Created is the key creation time. It is zero for the keys that
+	// were saved with older versions.
+	Created time.Time
+	KDF     KDFAlg
 }
 
 // List lists all identity keys.
@@ -50,29 +61,51 @@ func (s *Storage) List() ([]KeyInfo, error) {
 	}
 	var keys []KeyInfo
 	for _, fi := range info {
-		if fi.IsDir() {
+		if fi.IsDir() || strings.HasSuffix(fi.Name(), ".tmp") {
 			continue
 		}
-		data, err := s.loadKeyData(fi.Name())
+		ki, err := s.Info(fi.Name())
 		if err != nil {
-			log.Printf("Failed to read identity key %s: %s\n", fi.Name(), err)
+			log.Printf("Skipping identity key %s: %s\n", fi.Name(), err)
 			continue
 		}
-		enc := new(EncryptedKey)
-		if err = encoding.Unmarshal(bytes.NewReader(data), enc); err != nil {
-			log.Printf("Skipping unparseable identity key %s (%s)\n",
-				fi.Name(), err)
-			continue
-		}
-
-		keys = append(keys, KeyInfo{
-			ID:   fi.Name(),
-			Name: enc.Name,
-		})
+		keys = append(keys, ki)
 	}
 	return keys, nil
 }
 
+// Info returns information about the key id without decrypting it.
+func (s *Storage) Info(id string) (KeyInfo, error) {
+	data, err := s.loadKeyData(id)
+	if err != nil {
+		return KeyInfo{}, err
+	}
+	enc, err := ParseEncryptedKey(data)
+	if err != nil {
+		return KeyInfo{}, err
+	}
+	ki := KeyInfo{
+		ID:   id,
+		Name: enc.Name,
+		KDF:  enc.KDFAlg,
+	}
+	if len(enc.PublicKey) > 0 {
+		ki.PublicKey, err = UnmarshalPublicKey(enc.PublicKey)
+		if err != nil {
+			return KeyInfo{}, err
+		}
+	}
+	if enc.Created != 0 {
+		ki.Created = time.Unix(0, enc.Created)
+	}
+	return ki, nil
+}
+
+// Delete deletes the key id from the storage.
+func (s *Storage) Delete(id string) error {
+	return os.Remove(s.keyPath(id))
+}
+
 // Load loads the key id that is encrypted with the passphrase.
 func (s *Storage) Load(id string, passphrase []byte) (Key, error) {
 	encrypted, err := s.loadKeyData(id)
@@ -86,21 +119,51 @@ func (s *Storage) Load(id string, passphrase []byte) (Key, error) {
 	return Unmarshal(data)
 }
 
+func (s *Storage) keyPath(id string) string {
+	return fmt.Sprintf("%s/%s", s.Dir, id)
+}
+
 func (s *Storage) loadKeyData(id string) ([]byte, error) {
-	return ioutil.ReadFile(fmt.Sprintf("%s/%s", s.Dir, id))
+	return ioutil.ReadFile(s.keyPath(id))
 }
 
-// Save saves they key encrypted with the passphrase.
+// Save saves they key encrypted with the passphrase. If the storage
+// already has the key, its creation time is preserved.
 func (s *Storage) Save(key Key, passphrase []byte) error {
 	data, err := key.Marshal()
 	if err != nil {
 		return err
 	}
-	encrypted, err := Encrypt(data, EncrAES128GCM, key.Name(), passphrase,
-		KDFPBKDF24096SHA256)
+	enc, err := seal(data, EncrAES128GCM, key.Name(), passphrase, s.KDF)
+	if err != nil {
+		return err
+	}
+	if private, ok := key.(PrivateKey); ok {
+		enc.PublicKey, err = private.PublicKey().Marshal()
+		if err != nil {
+			return err
+		}
+	}
+	// The creation time of the keys that were saved by older versions
+	// is unknown.
+	if old, err := s.Info(key.ID()); err == nil {
+		if !old.Created.IsZero() {
+			enc.Created = old.Created.UnixNano()
+		}
+	} else {
+		enc.Created = time.Now().UnixNano()
+	}
+	encrypted, err := encoding.Marshal(enc)
 	if err != nil {
 		return err
 	}
-	path := fmt.Sprintf("%s/%s", s.Dir, key.ID())
-	return ioutil.WriteFile(path, encrypted, 0700)
+
+	// Write the key to a temporary file so an existing key is not
+	// damaged if the write fails.
+	path := s.keyPath(key.ID())
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, encrypted, 0700); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
 }
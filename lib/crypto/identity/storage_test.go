@@ -0,0 +1,87 @@
+//
+// Copyright (c) 2026 Markku Rossi
+//
+// All rights reserved.
+//
+
+package identity
+
+import (
+	"testing"
+)
+
+func TestStorage(t *testing.T) {
+	s := &Storage{
+		Dir: t.TempDir(),
+		KDF: KDFPBKDF24096SHA256,
+	}
+	key, err := NewEd25519Key("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	passphrase := []byte("passphrase")
+	if err := s.Save(key, passphrase); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	keys, err := s.List()
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(keys) != 1 {
+		t.Fatalf("got %d keys, expected 1", len(keys))
+	}
+	info := keys[0]
+	if info.ID != key.ID() || info.Name != "alice" || info.PublicKey == nil ||
+		info.PublicKey.ID() != key.ID() || info.Created.IsZero() ||
+		info.KDF != KDFPBKDF24096SHA256 {
+		t.Errorf("unexpected key info: %+v", info)
+	}
+
+	renamed, err := Rename(key, "bob")
+	if err != nil {
+		t.Fatalf("Rename failed: %v", err)
+	}
+	if renamed.ID() != key.ID() || renamed.Name() != "bob" {
+		t.Errorf("unexpected renamed key: %s %s", renamed.ID(), renamed.Name())
+	}
+	if err := s.Save(renamed, passphrase); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	info2, err := s.Info(key.ID())
+	if err != nil {
+		t.Fatalf("Info failed: %v", err)
+	}
+	if info2.Name != "bob" || !info2.Created.Equal(info.Created) {
+		t.Errorf("unexpected key info after rename: %+v", info2)
+	}
+	loaded, err := s.Load(key.ID(), passphrase)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if loaded.Name() != "bob" {
+		t.Errorf("loaded key name %s, expected bob", loaded.Name())
+	}
+
+	if err := s.Delete(key.ID()); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	keys, err = s.List()
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(keys) != 0 {
+		t.Errorf("got %d keys after delete", len(keys))
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	id := "sha256:" +
+		"a1c9e05b982f9ed74eccddea3c260fff0ad7f2537b22cfae4034b4c7b5e54f35"
+	fp := Fingerprint(id)
+	if fp != "SHA256:ocngW5gvntdOzN3qPCYP/wrX8lN7Is+uQDS0x7XlTzU" {
+		t.Errorf("unexpected fingerprint %s", fp)
+	}
+	if Fingerprint("foo") != "foo" {
+		t.Errorf("invalid ID fingerprint modified")
+	}
+}
@@ -0,0 +1,132 @@
+//
+// Copyright (c) 2026 Markku Rossi
+//
+// All rights reserved.
+//
+
+// Package shamir implements Shamir's secret sharing over GF(2^8).
+package shamir
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// The GF(2^8) arithmetic uses the AES polynomial x^8+x^4+x^3+x+1 and
+// the generator 3 for the logarithm tables.
+var (
+	expTable [510]byte
+	logTable [256]byte
+)
+
+func init() {
+	x := byte(1)
+	for i := 0; i < 255; i++ {
+		expTable[i] = x
+		expTable[i+255] = x
+		logTable[x] = byte(i)
+		// Multiply by the generator 3.
+		x ^= xtime(x)
+	}
+}
+
+// xtime multiplies a by x in GF(2^8).
+func xtime(a byte) byte {
+	if a&0x80 != 0 {
+		return a<<1 ^ 0x1b
+	}
+	return a << 1
+}
+
+func mul(a, b byte) byte {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return expTable[int(logTable[a])+int(logTable[b])]
+}
+
+func div(a, b byte) byte {
+	if a == 0 {
+		return 0
+	}
+	return expTable[int(logTable[a])+255-int(logTable[b])]
+}
+
+// Split splits the secret into n shares so that any k shares
+// reconstruct the secret. Each share is the share's x-coordinate
+// followed by len(secret) bytes of y-coordinates.
+func Split(secret []byte, n, k int) ([][]byte, error) {
+	if k < 1 || k > n || n > 255 {
+		return nil, fmt.Errorf("invalid threshold %d of %d", k, n)
+	}
+	if len(secret) == 0 {
+		return nil, errors.New("empty secret")
+	}
+	shares := make([][]byte, n)
+	for i := range shares {
+		shares[i] = make([]byte, 1+len(secret))
+		shares[i][0] = byte(i + 1)
+	}
+
+	coeffs := make([]byte, k)
+	for idx, s := range secret {
+		// Random polynomial of degree k-1 with the secret byte as
+		// its constant term.
+		coeffs[0] = s
+		if _, err := io.ReadFull(rand.Reader, coeffs[1:]); err != nil {
+			return nil, err
+		}
+		for _, share := range shares {
+			x := share[0]
+			var y byte
+			for i := k - 1; i >= 0; i-- {
+				y = mul(y, x) ^ coeffs[i]
+			}
+			share[1+idx] = y
+		}
+	}
+	return shares, nil
+}
+
+// Combine reconstructs the secret from the shares. The result is
+// correct only if there are at least as many shares as the split
+// threshold.
+func Combine(shares [][]byte) ([]byte, error) {
+	if len(shares) == 0 {
+		return nil, errors.New("no shares")
+	}
+	size := len(shares[0])
+	if size < 2 {
+		return nil, errors.New("truncated share")
+	}
+	seen := make(map[byte]bool)
+	for _, share := range shares {
+		if len(share) != size {
+			return nil, errors.New("share length mismatch")
+		}
+		if share[0] == 0 || seen[share[0]] {
+			return nil, fmt.Errorf("invalid share x-coordinate %d", share[0])
+		}
+		seen[share[0]] = true
+	}
+
+	// Lagrange interpolation at x=0.
+	secret := make([]byte, size-1)
+	for i, si := range shares {
+		var num, den byte = 1, 1
+		for j, sj := range shares {
+			if i == j {
+				continue
+			}
+			num = mul(num, sj[0])
+			den = mul(den, si[0]^sj[0])
+		}
+		basis := div(num, den)
+		for idx := range secret {
+			secret[idx] ^= mul(basis, si[1+idx])
+		}
+	}
+	return secret, nil
+}
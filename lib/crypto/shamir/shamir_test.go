@@ -0,0 +1,73 @@
+//
+// Copyright (c) 2026 Markku Rossi
+//
+// All rights reserved.
+//
+
+package shamir
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestMul(t *testing.T) {
+	// Test vector from FIPS-197 section 4.2.
+	if mul(0x57, 0x83) != 0xc1 {
+		t.Errorf("0x57*0x83=%#x, expected 0xc1", mul(0x57, 0x83))
+	}
+	for a := 1; a < 256; a++ {
+		for b := 1; b < 256; b++ {
+			if div(mul(byte(a), byte(b)), byte(b)) != byte(a) {
+				t.Fatalf("(%d*%d)/%d != %d", a, b, b, a)
+			}
+		}
+	}
+}
+
+func TestSplitCombine(t *testing.T) {
+	secret := []byte("Hello, world! This is a secret.")
+
+	for _, test := range []struct {
+		n, k int
+	}{
+		{1, 1}, {3, 1}, {3, 2}, {3, 3}, {5, 3}, {10, 7},
+	} {
+		shares, err := Split(secret, test.n, test.k)
+		if err != nil {
+			t.Fatalf("Split(%d, %d) failed: %v", test.n, test.k, err)
+		}
+		// Every window of k shares reconstructs the secret.
+		for start := 0; start+test.k <= test.n; start++ {
+			result, err := Combine(shares[start : start+test.k])
+			if err != nil {
+				t.Fatalf("Combine failed: %v", err)
+			}
+			if !bytes.Equal(result, secret) {
+				t.Errorf("%d of %d: shares %d-%d: secret mismatch",
+					test.k, test.n, start, start+test.k-1)
+			}
+		}
+		if test.k > 1 {
+			result, err := Combine(shares[:test.k-1])
+			if err != nil {
+				t.Fatalf("Combine failed: %v", err)
+			}
+			if bytes.Equal(result, secret) {
+				t.Errorf("%d of %d: %d shares reconstructed the secret",
+					test.k, test.n, test.k-1)
+			}
+		}
+	}
+
+	if _, err := Split(secret, 2, 3); err == nil {
+		t.Errorf("Split with threshold over share count succeeded")
+	}
+	shares, err := Split(secret, 3, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Combine([][]byte{shares[0], shares[0]}); err == nil {
+		t.Errorf("Combine with duplicate shares succeeded")
+	}
+}
@@ -0,0 +1,241 @@
+//
+// Copyright (c) 2026 Markku Rossi
+//
+// All rights reserved.
+//
+
+package zone
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/markkurossi/backup/lib/crypto/identity"
+	"github.com/markkurossi/backup/lib/persistence"
+)
+
+// The zone stores the public keys of its identities encrypted with
+// the zone secret under the public-keys namespace. The public keys
+// are used for listing the zone identities and for re-wrapping the
+// zone secret.
+func (zone *Zone) publicKeys() string {
+	return fmt.Sprintf("%s/public-keys", zone.Name)
+}
+
+// AddIdentity adds identity key for the zone.
+func (zone *Zone) AddIdentity(key identity.PublicKey) error {
+	if zone.writeOnly {
+		return ErrWriteOnly
+	}
+	encrypted, err := key.Encrypt(zone.secret)
+	if err != nil {
+		return err
+	}
+	if identity.IsRecoveryID(key.ID()) {
+		// The recovery key is symmetric so it is not stored.
+		return zone.Persistence.Set(zone.identities(), key.ID(), encrypted)
+	}
+	data, err := key.Marshal()
+	if err != nil {
+		return err
+	}
+	data, err = zone.encrypt(data)
+	if err != nil {
+		return err
+	}
+	err = zone.Persistence.Set(zone.publicKeys(), key.ID(), data)
+	if err != nil {
+		return err
+	}
+	return zone.Persistence.Set(zone.identities(), key.ID(), encrypted)
+}
+
+// Identity describes a zone identity.
+type Identity struct {
+	ID string
+	// Key is the identity's public key. It is nil for the identities
+	// that were added without the public key.
+	Key identity.PublicKey
+}
+
+// Identities returns the zone identities sorted by their IDs. The
+// function requires a persistence storage that supports GetAll.
+func (zone *Zone) Identities() ([]*Identity, error) {
+	if zone.writeOnly {
+		return nil, ErrWriteOnly
+	}
+	ids, err := zone.Persistence.GetAll(zone.identities())
+	if err != nil {
+		return nil, err
+	}
+	// The zones created with older versions do not have the public
+	// keys namespace.
+	keys, err := zone.Persistence.GetAll(zone.publicKeys())
+	if err != nil {
+		keys = nil
+	}
+	var result []*Identity
+	for id := range ids {
+		ident := &Identity{
+			ID: id,
+		}
+		if data, ok := keys[id]; ok {
+			ident.Key, err = zone.decryptPublicKey(data)
+			if err != nil {
+				return nil, fmt.Errorf("identity %s: %s", id, err)
+			}
+		}
+		result = append(result, ident)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ID < result[j].ID
+	})
+	return result, nil
+}
+
+func (zone *Zone) decryptPublicKey(data []byte) (identity.PublicKey, error) {
+	data, err := zone.decrypt(data)
+	if err != nil {
+		return nil, err
+	}
+	return identity.UnmarshalPublicKey(data)
+}
+
+// Revoke removes the identity id from the zone. The revoked identity
+// can't open the zone after the zone is rekeyed with Rekey. The
+// function refuses to remove the last zone identity unless the zone
+// can be opened with the zone secret shares.
+func (zone *Zone) Revoke(id string) error {
+	ids, err := zone.Identities()
+	if err != nil {
+		return err
+	}
+	var found bool
+	for _, ident := range ids {
+		if ident.ID == id {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return fmt.Errorf("identity %s not found", id)
+	}
+	if len(ids) == 1 {
+		holders, err := zone.ShareHolders()
+		if err != nil {
+			return err
+		}
+		if len(holders) == 0 {
+			return fmt.Errorf("can't revoke the last identity %s", id)
+		}
+	}
+	return zone.removeIdentity(id)
+}
+
+func (zone *Zone) removeIdentity(id string) error {
+	err := zone.Persistence.Delete(zone.identities(), id)
+	if err != nil {
+		return err
+	}
+	exists, err := zone.Persistence.Exists(zone.publicKeys(), id)
+	if err != nil || !exists {
+		return err
+	}
+	return zone.Persistence.Delete(zone.publicKeys(), id)
+}
+
+// removeIdentities removes all zone identities including the
+// recovery key.
+func (zone *Zone) removeIdentities() error {
+	ids, err := zone.Persistence.GetAll(zone.identities())
+	if err != nil {
+		// No identities namespace.
+		return nil
+	}
+	for id := range ids {
+		if err := zone.removeIdentity(id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// List lists the zones of the persistence storage. The function
+// requires a persistence storage that implements the
+// persistence.NamespaceLister interface.
+func List(p persistence.Reader) ([]string, error) {
+	lister, ok := p.(persistence.NamespaceLister)
+	if !ok {
+		return nil, fmt.Errorf("persistence storage can't list zones")
+	}
+	names, err := lister.Namespaces("")
+	if err != nil {
+		return nil, err
+	}
+	var result []string
+	for _, name := range names {
+		exists, err := p.Exists(name, rootPointer)
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			result = append(result, name)
+		}
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+// IdentityIDs returns the identity IDs of the zone name. The function
+// does not need a key to open the zone but it requires a persistence
+// storage that supports GetAll.
+func IdentityIDs(p persistence.Reader, name string) ([]string, error) {
+	ids, err := p.GetAll((&Zone{Name: name}).identities())
+	if err != nil {
+		return nil, err
+	}
+	var result []string
+	for id := range ids {
+		result = append(result, id)
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+// SetRecoveryKey sets the zone recovery key. The function removes the
+// previous recovery key of the zone.
+func (zone *Zone) SetRecoveryKey(key identity.PrivateKey) error {
+	if !identity.IsRecoveryID(key.ID()) {
+		return fmt.Errorf("key %s is not a recovery key", key.ID())
+	}
+	if err := zone.AddIdentity(key.PublicKey()); err != nil {
+		return err
+	}
+	ids, err := zone.Persistence.GetAll(zone.identities())
+	if err != nil {
+		return err
+	}
+	for id := range ids {
+		if identity.IsRecoveryID(id) && id != key.ID() {
+			if err := zone.Persistence.Delete(zone.identities(), id); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// HasRecoveryKey tests if the zone name has a recovery key. The
+// function does not need a key to open the zone.
+func HasRecoveryKey(p persistence.Reader, name string) (bool, error) {
+	ids, err := IdentityIDs(p, name)
+	if err != nil {
+		return false, err
+	}
+	for _, id := range ids {
+		if identity.IsRecoveryID(id) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,76 @@
+//
+// Copyright (c) 2026 Markku Rossi
+//
+// All rights reserved.
+//
+
+package zone
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/markkurossi/backup/lib/crypto/identity"
+	"github.com/markkurossi/backup/lib/storage"
+)
+
+func TestRecoveryKey(t *testing.T) {
+	z, keys := newTestZone(t, "alice")
+	root := z.Persistence
+	alice := keys[0]
+	if err := z.SetRootPointer(storage.EmptyID); err != nil {
+		t.Fatalf("SetRootPointer failed: %v", err)
+	}
+
+	old, _, err := identity.NewRecoveryKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := z.SetRecoveryKey(old); err != nil {
+		t.Fatalf("SetRecoveryKey failed: %v", err)
+	}
+	recovery, text, err := identity.NewRecoveryKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := z.SetRecoveryKey(recovery); err != nil {
+		t.Fatalf("SetRecoveryKey failed: %v", err)
+	}
+	ids, err := IdentityIDs(root, "test")
+	if err != nil {
+		t.Fatalf("IdentityIDs failed: %v", err)
+	}
+	if len(ids) != 2 {
+		t.Errorf("got %d identities, expected 2", len(ids))
+	}
+
+	_, err = Open(root, "test", nil)
+	if !errors.Is(err, ErrNoKey) {
+		t.Fatalf("Open without keys: got %v, expected ErrNoKey", err)
+	}
+	if _, err := Open(root, "test", []identity.PrivateKey{old}); err == nil {
+		t.Fatalf("Previous recovery key opened the zone")
+	}
+	key, err := identity.ParseRecoveryKey(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	z, err = Open(root, "test", []identity.PrivateKey{key})
+	if err != nil {
+		t.Fatalf("Open with recovery key failed: %v", err)
+	}
+
+	if err := z.Rekey(nil); err != nil {
+		t.Fatalf("Rekey failed: %v", err)
+	}
+	ok, err := HasRecoveryKey(root, "test")
+	if err != nil {
+		t.Fatalf("HasRecoveryKey failed: %v", err)
+	}
+	if ok {
+		t.Errorf("Recovery key not removed in rekey")
+	}
+	if _, err := Open(root, "test", []identity.PrivateKey{alice}); err != nil {
+		t.Fatalf("Open after rekey failed: %v", err)
+	}
+}
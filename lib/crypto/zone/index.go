@@ -0,0 +1,134 @@
+//
+// Copyright (c) 2026 Markku Rossi
+//
+// All rights reserved.
+//
+
+package zone
+
+import (
+	"fmt"
+
+	"github.com/markkurossi/backup/lib/storage"
+	"github.com/markkurossi/backup/lib/tree"
+)
+
+func (zone *Zone) readIndex(id storage.ID) error {
+	element, err := tree.DeserializeID(id, zone)
+	if err != nil {
+		return err
+	}
+	index, ok := element.(*tree.SnapshotIndex)
+	if !ok {
+		return fmt.Errorf("object %s is not a snapshot index (%T)", id, element)
+	}
+	zone.Index = index
+	zone.IndexID = id
+	return nil
+}
+
+// loadIndex returns the zone's snapshot index. If the zone does not
+// have an index, the function creates it from the snapshot chain
+// that starts from the head snapshot.
+func (zone *Zone) loadIndex() (*tree.SnapshotIndex, error) {
+	if zone.Index != nil {
+		return zone.Index, nil
+	}
+	index := tree.NewSnapshotIndex()
+	for id := zone.HeadID; !id.Undefined(); {
+		element, err := tree.DeserializeID(id, zone)
+		if err != nil {
+			return nil, fmt.Errorf("failed to deserialize ID '%s': %s", id, err)
+		}
+		snapshot, ok := element.(*tree.Snapshot)
+		if !ok {
+			return nil, fmt.Errorf("ID %s is not a snapshot", id)
+		}
+		index.Add(id, snapshot)
+		id = snapshot.Parent
+	}
+	return index, nil
+}
+
+// saveIndex writes the snapshot index, sets the head snapshot to the
+// newest indexed snapshot that a full identity wrote, and updates the
+// root pointer. The writers choose the timestamps of their snapshots
+// so their snapshots never become the head snapshot.
+func (zone *Zone) saveIndex(index *tree.SnapshotIndex) error {
+	data, err := index.Serialize()
+	if err != nil {
+		return err
+	}
+	indexID, err := zone.Write(data)
+	if err != nil {
+		return err
+	}
+
+	var head *tree.Snapshot
+	var headID storage.ID
+	for _, entry := range index.Snapshots {
+		snapshot, e, err := zone.readSnapshot(entry.ID)
+		if err != nil || zone.writerEpoch(e) {
+			continue
+		}
+		head = snapshot
+		headID = entry.ID
+		break
+	}
+
+	zone.Index = index
+	zone.IndexID = indexID
+	zone.Head = head
+	zone.HeadID = headID
+
+	if err := zone.SetRootPointer(headID); err != nil {
+		return err
+	}
+	return zone.removeMerged()
+}
+
+// AddSnapshot adds the snapshot id to the zone's snapshot index and
+// updates the root pointer. The write-only identities add the
+// snapshot to the zone's pending snapshots instead.
+func (zone *Zone) AddSnapshot(id storage.ID, snapshot *tree.Snapshot) error {
+	if zone.writeOnly {
+		return zone.addPending(id)
+	}
+	index, err := zone.loadIndex()
+	if err != nil {
+		return err
+	}
+	index.Add(id, snapshot)
+	return zone.saveIndex(index)
+}
+
+// ForgetSnapshot removes the snapshot id from the zone's snapshot
+// index. The snapshot objects and the parent references of the
+// newer snapshots are not modified. If the snapshot is the head
+// snapshot, the newest remaining snapshot of the full identities
+// becomes the head snapshot.
+func (zone *Zone) ForgetSnapshot(id storage.ID) error {
+	index, err := zone.loadIndex()
+	if err != nil {
+		return err
+	}
+	if !index.Remove(id) {
+		return fmt.Errorf("snapshot %s not found", id)
+	}
+	return zone.saveIndex(index)
+}
+
+// RebuildIndex rebuilds the zone's snapshot index by scanning all
+// zone objects for snapshots. The function requires a persistence
+// storage that supports GetAll. The function returns the number of
+// snapshots found.
+func (zone *Zone) RebuildIndex() (int, error) {
+	index := tree.NewSnapshotIndex()
+	zone.scanSnapshots(func(id storage.ID, snapshot *tree.Snapshot) {
+		index.Add(id, snapshot)
+	})
+	if len(index.Snapshots) == 0 {
+		return 0, fmt.Errorf("no snapshots found from object store")
+	}
+	return len(index.Snapshots), zone.saveIndex(index)
+}
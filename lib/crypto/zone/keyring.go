@@ -0,0 +1,344 @@
+//
+// Copyright (c) 2026 Markku Rossi
+//
+// All rights reserved.
+//
+
+package zone
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+
+	"github.com/markkurossi/backup/lib/crypto/identity"
+	"github.com/markkurossi/backup/lib/encoding"
+	"github.com/markkurossi/backup/lib/storage"
+	"github.com/markkurossi/backup/lib/tree"
+)
+
+// epoch holds the keys derived from a zone secret.
+type epoch struct {
+	idHash    hash.Hash
+	newIDHash func() hash.Hash
+	cipher    cipher.Block
+	newHMAC   func() hash.Hash
+	// logSeq is the sequence number of the first root pointer log
+	// entry that was written after the secret was rekeyed.
+	logSeq int64
+	// index is the zone's snapshot index when the secret was
+	// rekeyed.
+	index     storage.ID
+	snapshots map[string]bool
+	// writer is the ID of the write-only identity if the keys are
+	// writer data keys. The active writer keys are the current data
+	// keys of the zone writers.
+	writer string
+	active bool
+}
+
+func newEpoch(secret []byte, suite Suite) (*epoch, error) {
+	split1 := suite.IDHashKeyLen()
+	split2 := split1 + suite.CipherKeyLen()
+
+	switch suite {
+	case AES256CBCHMACSHA256:
+		block, err := aes.NewCipher(secret[split1:split2])
+		if err != nil {
+			return nil, err
+		}
+		idKey := secret[:split1]
+		hmacKey := secret[split2:]
+		newIDHash := func() hash.Hash {
+			return hmac.New(sha256.New, idKey)
+		}
+		return &epoch{
+			idHash:    newIDHash(),
+			newIDHash: newIDHash,
+			cipher:    block,
+			newHMAC: func() hash.Hash {
+				return hmac.New(sha256.New, hmacKey)
+			},
+		}, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported suite: %s", suite)
+	}
+}
+
+// verifyID verifies that id is the ID of the object data.
+func (e *epoch) verifyID(id storage.ID, data []byte) error {
+	h := e.newIDHash()
+	h.Write(data)
+	if !hmac.Equal(h.Sum(nil), id.Data) {
+		return fmt.Errorf("%w %s", ErrObjectID, id)
+	}
+	return nil
+}
+
+// ErrObjectID is returned when the object content does not match its
+// ID.
+var ErrObjectID = errors.New("object content does not match ID")
+
+// The zone keyring holds the previous zone secrets. When the zone
+// is rekeyed, the new zone secret is wrapped for the zone identities
+// and the previous secrets are stored in the keyring encrypted with
+// the new secret. The objects are not re-encrypted but they are
+// decrypted with the previous secrets. The keyring is stored under
+// the keyring ID of the secret that encrypts it so the identities
+// that still wrap an older secret can open the zone if the rekey is
+// interrupted.
+//
+// A revoked identity may know the previous secrets so the keyring
+// also records the epoch boundaries of the secrets: the first root
+// pointer log entry and the snapshot index after the rekey. The root
+// pointer log entries are verified with a previous secret only if
+// they are older than its rekey, and the snapshots that are found by
+// scanning the zone objects are trusted only if they were in the
+// snapshot index of the rekey. The other objects are trusted because
+// their IDs are verified from their content when they are read
+// through the trusted snapshots.
+func (zone *Zone) keyrings() string {
+	return fmt.Sprintf("%s/keyring", zone.Name)
+}
+
+func keyringID(secret []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte("keyring"))
+	return fmt.Sprintf("%x", mac.Sum(nil)[:16])
+}
+
+// keyring defines the zone keyring object. The keyrings that were
+// written before the epoch boundaries were recorded end after
+// Secrets.
+type keyring struct {
+	Secrets [][]byte
+	LogSeqs []int64
+	Indexes []storage.ID
+}
+
+// loadKeyring loads the previous zone secrets from the keyring of
+// the current zone secret.
+func (zone *Zone) loadKeyring() error {
+	id := keyringID(zone.secret)
+	exists, err := zone.Persistence.Exists(zone.keyrings(), id)
+	if err != nil || !exists {
+		return err
+	}
+	data, err := zone.Persistence.Get(zone.keyrings(), id, 0)
+	if err != nil {
+		return err
+	}
+	data, err = zone.decrypt(data)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt keyring: %s", err)
+	}
+	kr := new(keyring)
+	err = encoding.Unmarshal(bytes.NewReader(data), kr)
+	if err == io.EOF && len(kr.Secrets) > 0 && kr.LogSeqs == nil {
+		// Keyring without epoch boundaries. None of the root pointer
+		// log entries or the scanned snapshots of the previous
+		// secrets are trusted.
+		err = nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to decode keyring: %s", err)
+	}
+	if kr.LogSeqs != nil && (len(kr.LogSeqs) != len(kr.Secrets) ||
+		len(kr.Indexes) != len(kr.Secrets)) {
+		return errors.New("invalid keyring epoch boundaries")
+	}
+	zone.epochs = nil
+	zone.secrets = nil
+	for idx, secret := range kr.Secrets {
+		if len(secret) != zone.suite.KeyLen() {
+			return fmt.Errorf("invalid keyring secret length: %d",
+				len(secret))
+		}
+		e, err := newEpoch(secret, zone.suite)
+		if err != nil {
+			return err
+		}
+		if kr.LogSeqs != nil {
+			e.logSeq = kr.LogSeqs[idx]
+			e.index = kr.Indexes[idx]
+		}
+		zone.epochs = append(zone.epochs, e)
+		zone.secrets = append(zone.secrets, secret)
+	}
+	return nil
+}
+
+// trustedSnapshot tests if the snapshot id that was decrypted with
+// the keys e can be trusted when it is found by scanning the zone
+// objects. The snapshots of the previous zone secrets are trusted
+// only if they were in the snapshot index of the rekey.
+func (zone *Zone) trustedSnapshot(e *epoch, id storage.ID) bool {
+	var previous bool
+	for _, prev := range zone.epochs {
+		if prev == e {
+			previous = true
+			break
+		}
+	}
+	if !previous {
+		return true
+	}
+	if e.snapshots == nil {
+		e.snapshots = make(map[string]bool)
+		if e.index.Undefined() {
+			return false
+		}
+		element, err := tree.DeserializeID(e.index, zone)
+		if err != nil {
+			return false
+		}
+		index, ok := element.(*tree.SnapshotIndex)
+		if !ok {
+			return false
+		}
+		for _, entry := range index.Snapshots {
+			e.snapshots[string(entry.ID.Data)] = true
+		}
+	}
+	return e.snapshots[string(id.Data)]
+}
+
+// Rekey creates a new zone secret and wraps it for all zone
+// identities. The new secret has the same object ID hash key as the
+// previous secret. The new objects are encrypted with the new secret
+// and the existing objects are decrypted with the previous
+// secrets. The identities that were added without public keys are
+// wrapped with the matching keys from keys; Rekey fails if an
+// identity does not have a public key. The zone recovery key can't
+// be re-wrapped without the recovery key so Rekey removes it. The
+// zone secret shares are re-split for the current share holders and
+// the writers get new data keys that are wrapped for the active
+// writers. The previous writer data keys are kept for decrypting the
+// writers' objects.
+func (zone *Zone) Rekey(keys []identity.PublicKey) error {
+	// Save the merged pending snapshots so they are in the snapshot
+	// index of the rekey. The pending snapshots of the previous
+	// writer data keys are not merged after the rekey.
+	if err := zone.savePending(); err != nil {
+		return err
+	}
+	ids, err := zone.Identities()
+	if err != nil {
+		return err
+	}
+	holders, err := zone.ShareHolders()
+	if err != nil {
+		return err
+	}
+	if len(ids) == 0 && len(holders) == 0 {
+		return errors.New("zone has no identities or share holders")
+	}
+	var wrap []identity.PublicKey
+	var recovery []string
+	for _, id := range ids {
+		if identity.IsRecoveryID(id.ID) {
+			recovery = append(recovery, id.ID)
+			continue
+		}
+		key := id.Key
+		if key == nil {
+			for _, k := range keys {
+				if k.ID() == id.ID {
+					key = k
+					break
+				}
+			}
+		}
+		if key == nil {
+			return fmt.Errorf("identity %s has no public key", id.ID)
+		}
+		wrap = append(wrap, key)
+	}
+
+	writerKeys, err := zone.readWriterKeys()
+	if err != nil {
+		return err
+	}
+
+	// The new secret keeps the object ID hash key so that the new
+	// objects are deduplicated against the existing objects.
+	secret := make([]byte, zone.suite.KeyLen())
+	split := zone.suite.IDHashKeyLen()
+	copy(secret, zone.secret[:split])
+	if _, err := io.ReadFull(rand.Reader, secret[split:]); err != nil {
+		return err
+	}
+	prev, err := newEpoch(zone.secret, zone.suite)
+	if err != nil {
+		return err
+	}
+	seq, err := zone.lastLogSeq()
+	if err != nil {
+		return err
+	}
+	prev.logSeq = int64(seq + 1)
+	prev.index = zone.IndexID
+
+	secrets := append([][]byte{zone.secret}, zone.secrets...)
+	epochs := append([]*epoch{prev}, zone.epochs...)
+	var logSeqs []int64
+	var indexes []storage.ID
+	for _, e := range epochs {
+		logSeqs = append(logSeqs, e.logSeq)
+		indexes = append(indexes, e.index)
+	}
+
+	if err := zone.init(secret, zone.suite); err != nil {
+		return err
+	}
+	zone.secrets = secrets
+	zone.epochs = epochs
+
+	data, err := encoding.Marshal(&keyring{
+		Secrets: secrets,
+		LogSeqs: logSeqs,
+		Indexes: indexes,
+	})
+	if err != nil {
+		return err
+	}
+	data, err = zone.encrypt(data)
+	if err != nil {
+		return err
+	}
+	err = zone.Persistence.Set(zone.keyrings(), keyringID(secret), data)
+	if err != nil {
+		return err
+	}
+	for _, key := range wrap {
+		if err := zone.AddIdentity(key); err != nil {
+			return err
+		}
+	}
+	if err := zone.rekeyWriters(writerKeys); err != nil {
+		return err
+	}
+	for _, id := range recovery {
+		if err := zone.Persistence.Delete(zone.identities(), id); err != nil {
+			return err
+		}
+	}
+	if len(holders) > 0 {
+		var pubs []identity.PublicKey
+		for _, holder := range holders {
+			pubs = append(pubs, holder.Key)
+		}
+		if err := zone.Split(holders[0].Threshold, pubs, false); err != nil {
+			return err
+		}
+	}
+	return zone.SetRootPointer(zone.HeadID)
+}
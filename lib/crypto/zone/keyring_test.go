@@ -0,0 +1,161 @@
+//
+// Copyright (c) 2026 Markku Rossi
+//
+// All rights reserved.
+//
+
+package zone
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/markkurossi/backup/lib/crypto/identity"
+	"github.com/markkurossi/backup/lib/storage"
+	"github.com/markkurossi/backup/lib/tree"
+)
+
+func TestRekey(t *testing.T) {
+	z, keys := newTestZone(t, "alice", "bob")
+	root := z.Persistence
+	alice, bob := keys[0], keys[1]
+	if err := z.SetRootPointer(storage.EmptyID); err != nil {
+		t.Fatalf("SetRootPointer failed: %v", err)
+	}
+	data1 := []byte("Hello, world!")
+	id1, err := z.Write(data1)
+	if err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	for round := 0; round < 2; round++ {
+		if err := z.Rekey(nil); err != nil {
+			t.Fatalf("Rekey failed: %v", err)
+		}
+	}
+	if err := z.Revoke(bob.ID()); err != nil {
+		t.Fatalf("Revoke failed: %v", err)
+	}
+	if err := z.Revoke(alice.ID()); err == nil {
+		t.Fatalf("Revoked the last identity")
+	}
+	if err := z.Rekey(nil); err != nil {
+		t.Fatalf("Rekey failed: %v", err)
+	}
+	// The rekeyed zone deduplicates against the existing objects.
+	id, err := z.Write(data1)
+	if err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if !id.Equal(id1) || z.Dedup != uint64(len(data1)) {
+		t.Errorf("rekeyed zone did not deduplicate: %s vs %s", id, id1)
+	}
+	data2 := []byte("Hello, rekeyed world!")
+	id2, err := z.Write(data2)
+	if err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	if _, err := Open(root, "test", []identity.PrivateKey{bob}); err == nil {
+		t.Fatalf("Revoked identity opened the zone")
+	}
+	z, err = Open(root, "test", []identity.PrivateKey{alice})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	if len(z.epochs) != 3 {
+		t.Errorf("got %d epochs, expected 3", len(z.epochs))
+	}
+	for _, test := range []struct {
+		id   storage.ID
+		data []byte
+	}{
+		{id1, data1},
+		{id2, data2},
+	} {
+		data, err := z.Read(test.id)
+		if err != nil {
+			t.Fatalf("Read failed: %v", err)
+		}
+		if !bytes.Equal(data, test.data) {
+			t.Errorf("Read data mismatch: got %q, expected %q",
+				data, test.data)
+		}
+	}
+	ids, err := z.Identities()
+	if err != nil {
+		t.Fatalf("Identities failed: %v", err)
+	}
+	if len(ids) != 1 || ids[0].ID != alice.ID() || ids[0].Key == nil {
+		t.Errorf("unexpected identities: %v", ids)
+	}
+}
+
+func TestRekeyForgery(t *testing.T) {
+	z, keys := newTestZone(t, "alice")
+	root := z.Persistence
+	alice := keys[0]
+	s := tree.NewSnapshot()
+	s.Timestamp = 1
+	data, err := s.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	headID, err := z.Write(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := z.AddSnapshot(headID, s); err != nil {
+		t.Fatalf("AddSnapshot failed: %v", err)
+	}
+
+	// The revoked identity keeps the secret of the rekeyed zone.
+	revoked := newZone("test", root)
+	if err := revoked.init(z.secret, suite); err != nil {
+		t.Fatal(err)
+	}
+	if err := z.Rekey(nil); err != nil {
+		t.Fatalf("Rekey failed: %v", err)
+	}
+
+	forged := tree.NewSnapshot()
+	forged.Timestamp = 2
+	data, err = forged.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	forgedID, err := revoked.Write(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := revoked.SetRootPointer(forgedID); err != nil {
+		t.Fatal(err)
+	}
+
+	z, err = Open(root, "test", []identity.PrivateKey{alice})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	if !z.HeadID.Equal(headID) {
+		t.Errorf("forged root pointer accepted: %s", z.HeadID)
+	}
+	n, err := z.RebuildIndex()
+	if err != nil || n != 1 {
+		t.Errorf("RebuildIndex: got %d snapshots, expected 1: %v", n, err)
+	}
+
+	// Replacing an object with another object is detected.
+	ns, key := z.objectNames(headID)
+	otherNS, otherKey := z.objectNames(forgedID)
+	other, err := root.Get(otherNS, otherKey, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := root.Set(ns, key, other); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := z.Read(headID); !errors.Is(err, ErrObjectID) {
+		t.Errorf("replaced object: got %v, expected ErrObjectID", err)
+	}
+}
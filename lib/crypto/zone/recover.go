@@ -0,0 +1,261 @@
+//
+// Copyright (c) 2026 Markku Rossi
+//
+// All rights reserved.
+//
+
+package zone
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/markkurossi/backup/lib/persistence"
+	"github.com/markkurossi/backup/lib/storage"
+	"github.com/markkurossi/backup/lib/tree"
+)
+
+// logRecovery defines how many of the newest root pointer log
+// entries are tried when recovering the root pointer.
+const logRecovery = 16
+
+// The root pointer log is an append-only sequence of root pointers
+// stored under the keys 0, 1, 2, ... of the zone's log
+// namespace. The log does not require GetAll from the persistence
+// storage since its last entry is located with Exists probes.
+func (zone *Zone) logNamespace() string {
+	return fmt.Sprintf("%s/log", zone.Name)
+}
+
+func (zone *Zone) logExists(seq int) (bool, error) {
+	return zone.Persistence.Exists(zone.logNamespace(), strconv.Itoa(seq))
+}
+
+// lastLogSeq returns the sequence number of the last root pointer
+// log entry or -1 if the log is empty.
+func (zone *Zone) lastLogSeq() (int, error) {
+	exists, err := zone.logExists(0)
+	if err != nil || !exists {
+		return -1, err
+	}
+	// Find an upper bound with exponential probes and binary search
+	// the last entry between the bounds.
+	lo := 0
+	hi := 1
+	for {
+		exists, err = zone.logExists(hi)
+		if err != nil {
+			return -1, err
+		}
+		if !exists {
+			break
+		}
+		lo = hi
+		hi *= 2
+	}
+	for lo+1 < hi {
+		mid := (lo + hi) / 2
+		exists, err = zone.logExists(mid)
+		if err != nil {
+			return -1, err
+		}
+		if exists {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return lo, nil
+}
+
+// appendLog appends the marshaled root pointer to the root pointer
+// log. The last log sequence number is located once and cached in
+// the zone. The log entries are created only if they don't exist so
+// if another writer has appended to the log, the entry is appended
+// after its entries.
+func (zone *Zone) appendLog(data []byte) error {
+	if !zone.logSeqValid {
+		seq, err := zone.lastLogSeq()
+		if err != nil {
+			return err
+		}
+		zone.logSeq = seq
+		zone.logSeqValid = true
+	}
+	for {
+		seq := zone.logSeq + 1
+		err := zone.Persistence.Create(zone.logNamespace(),
+			strconv.Itoa(seq), data)
+		if err != nil && !errors.Is(err, persistence.ErrExists) {
+			return err
+		}
+		zone.logSeq = seq
+		if err == nil {
+			return nil
+		}
+	}
+}
+
+// readLog reads and verifies the root pointer log entry seq.
+func (zone *Zone) readLog(seq int) (*RootPointer, error) {
+	data, err := zone.Persistence.Get(zone.logNamespace(),
+		strconv.Itoa(seq), 0)
+	if err != nil {
+		return nil, err
+	}
+	ptr, err := unmarshalRootPointer(data)
+	if err != nil {
+		return nil, err
+	}
+	if err := zone.checkRootPointer(ptr, seq); err != nil {
+		return nil, err
+	}
+	return ptr, nil
+}
+
+// lastLogPointer returns the newest valid root pointer from the root
+// pointer log and its sequence number.
+func (zone *Zone) lastLogPointer() (*RootPointer, int, error) {
+	last, err := zone.lastLogSeq()
+	if err != nil {
+		return nil, -1, err
+	}
+	if last < 0 {
+		return nil, -1, errors.New("root pointer log is empty")
+	}
+	for seq := last; seq >= 0 && seq > last-logRecovery; seq-- {
+		ptr, err := zone.readLog(seq)
+		if err == nil {
+			return ptr, seq, nil
+		}
+	}
+	return nil, -1, errors.New("no valid root pointer log entries")
+}
+
+// RecoveryCandidate describes a root pointer that was found in
+// recovery.
+type RecoveryCandidate struct {
+	// Source describes where the root pointer was found.
+	Source    string
+	Timestamp int64
+	Pointer   storage.ID
+	Index     storage.ID
+	// Snapshot is the head snapshot of the root pointer.
+	Snapshot *tree.Snapshot
+	// Scanned tells if the candidate was found by scanning the zone
+	// objects. The scanned candidates don't have a root pointer and
+	// their Timestamp is the snapshot timestamp.
+	Scanned bool
+	// Err describes why the root pointer is not valid.
+	Err error
+}
+
+// Valid tests if the candidate root pointer is valid.
+func (c *RecoveryCandidate) Valid() bool {
+	return c.Err == nil
+}
+
+func (zone *Zone) newCandidate(source string, ptr *RootPointer,
+	err error) *RecoveryCandidate {
+
+	c := &RecoveryCandidate{
+		Source: source,
+		Err:    err,
+	}
+	if err != nil {
+		return c
+	}
+	c.Timestamp = ptr.Timestamp
+	c.Pointer = ptr.Pointer
+	c.Index = ptr.Index
+	if c.Pointer.Undefined() {
+		return c
+	}
+	element, err := tree.DeserializeID(c.Pointer, zone)
+	if err != nil {
+		c.Err = fmt.Errorf("failed to deserialize snapshot: %s", err)
+		return c
+	}
+	snapshot, ok := element.(*tree.Snapshot)
+	if !ok {
+		c.Err = fmt.Errorf("root is not a snapshot (%T)", element)
+		return c
+	}
+	c.Snapshot = snapshot
+	if !c.Index.Undefined() {
+		if _, err := tree.DeserializeID(c.Index, zone); err != nil {
+			c.Err = fmt.Errorf("failed to read snapshot index: %s", err)
+		}
+	}
+	return c
+}
+
+// RecoveryCandidates finds root pointer candidates from the root
+// pointer copies and the newest root pointer log entries. If scan
+// is true, the function also scans all zone objects for the newest
+// snapshot. The scan requires a persistence storage that supports
+// GetAll.
+func (zone *Zone) RecoveryCandidates(scan bool) []*RecoveryCandidate {
+	var result []*RecoveryCandidate
+
+	ptrs, errs := zone.rootPointerCopies()
+	for i := 0; i < 2; i++ {
+		result = append(result, zone.newCandidate(
+			fmt.Sprintf("%s[%d]", rootPointer, i), ptrs[i], errs[i]))
+	}
+
+	last, err := zone.lastLogSeq()
+	if err != nil {
+		result = append(result, zone.newCandidate("log", nil, err))
+	} else if last < 0 {
+		result = append(result, zone.newCandidate("log", nil,
+			errors.New("root pointer log is empty")))
+	}
+	for seq := last; seq >= 0 && seq > last-logRecovery; seq-- {
+		ptr, err := zone.readLog(seq)
+		result = append(result,
+			zone.newCandidate(fmt.Sprintf("log/%d", seq), ptr, err))
+	}
+
+	if scan {
+		var best *tree.Snapshot
+		var bestID storage.ID
+		zone.scanSnapshots(func(id storage.ID, snapshot *tree.Snapshot) {
+			if best == nil || snapshot.Timestamp > best.Timestamp {
+				best = snapshot
+				bestID = id
+			}
+		})
+		c := &RecoveryCandidate{
+			Source:  "scan",
+			Scanned: true,
+		}
+		if best == nil {
+			c.Err = errors.New("no snapshots found from object store")
+		} else {
+			c.Timestamp = best.Timestamp
+			c.Pointer = bestID
+			c.Snapshot = best
+		}
+		result = append(result, c)
+	}
+
+	return result
+}
+
+// Recover sets the zone's head snapshot and snapshot index from the
+// candidate and rewrites the root pointer.
+func (zone *Zone) Recover(c *RecoveryCandidate) error {
+	if !c.Valid() {
+		return fmt.Errorf("invalid root pointer candidate: %s", c.Err)
+	}
+	err := zone.setHead(&RootPointer{
+		Pointer: c.Pointer,
+		Index:   c.Index,
+	})
+	if err != nil {
+		return err
+	}
+	return zone.SetRootPointer(zone.HeadID)
+}
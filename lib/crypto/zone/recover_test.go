@@ -0,0 +1,56 @@
+//
+// Copyright (c) 2026 Markku Rossi
+//
+// All rights reserved.
+//
+
+package zone
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/markkurossi/backup/lib/crypto/identity"
+	"github.com/markkurossi/backup/lib/persistence"
+	"github.com/markkurossi/backup/lib/storage"
+)
+
+func TestAppendLog(t *testing.T) {
+	z1, keys := newTestZone(t, "alice")
+	root := z1.Persistence
+	alice := keys[0]
+	if err := z1.SetRootPointer(storage.EmptyID); err != nil {
+		t.Fatalf("SetRootPointer failed: %v", err)
+	}
+	z2, err := Open(root, "test", []identity.PrivateKey{alice})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+
+	// Both writers append to the log. The first writer's cached
+	// sequence number is behind the second writer's entry.
+	for _, z := range []*Zone{z1, z2, z1} {
+		if err := z.SetRootPointer(storage.EmptyID); err != nil {
+			t.Fatalf("SetRootPointer failed: %v", err)
+		}
+	}
+	last, err := z1.lastLogSeq()
+	if err != nil {
+		t.Fatalf("lastLogSeq failed: %v", err)
+	}
+	if last != 3 || z1.logSeq != 3 || z2.logSeq != 2 {
+		t.Errorf("log: last %d, writers %d and %d, expected 3, 3, and 2",
+			last, z1.logSeq, z2.logSeq)
+	}
+	for seq := 0; seq <= last; seq++ {
+		if _, err := z1.readLog(seq); err != nil {
+			t.Errorf("readLog(%d) failed: %v", seq, err)
+		}
+	}
+
+	// Creating an existing entry fails.
+	err = root.Create(z1.logNamespace(), "0", nil)
+	if !errors.Is(err, persistence.ErrExists) {
+		t.Errorf("Create: got %v, expected ErrExists", err)
+	}
+}
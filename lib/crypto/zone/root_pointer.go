@@ -7,13 +7,109 @@
 package zone
 
 import (
+	"bytes"
+	"errors"
+
+	"github.com/markkurossi/backup/lib/encoding"
 	"github.com/markkurossi/backup/lib/storage"
 )
 
-// RootPointer implements zone root pointer.
+// RootPointerVersion defines the current root pointer version.
+const RootPointerVersion = 3
+
+// RootPointer implements zone root pointer. The version 1 root
+// pointers do not have the Index field and the version 2 root
+// pointers do not have the Signer and Signature fields.
 type RootPointer struct {
+	Version   byte
+	Timestamp int64
+	Pointer   storage.ID
+	Index     storage.ID
+	Digest    []byte
+	Signer    string
+	Signature []byte
+}
+
+type rootPointerV1 struct {
 	Version   byte
 	Timestamp int64
 	Pointer   storage.ID
 	Digest    []byte
 }
+
+type rootPointerV2 struct {
+	Version   byte
+	Timestamp int64
+	Pointer   storage.ID
+	Index     storage.ID
+	Digest    []byte
+}
+
+func (ptr *RootPointer) marshal() ([]byte, error) {
+	switch ptr.Version {
+	case 0, 1:
+		return encoding.Marshal(&rootPointerV1{
+			Version:   ptr.Version,
+			Timestamp: ptr.Timestamp,
+			Pointer:   ptr.Pointer,
+			Digest:    ptr.Digest,
+		})
+
+	case 2:
+		return encoding.Marshal(&rootPointerV2{
+			Version:   ptr.Version,
+			Timestamp: ptr.Timestamp,
+			Pointer:   ptr.Pointer,
+			Index:     ptr.Index,
+			Digest:    ptr.Digest,
+		})
+
+	default:
+		return encoding.Marshal(ptr)
+	}
+}
+
+// signedData returns the root pointer data that the digest and the
+// signature cover.
+func (ptr *RootPointer) signedData() ([]byte, error) {
+	unsigned := *ptr
+	unsigned.Digest = nil
+	unsigned.Signature = nil
+	return unsigned.marshal()
+}
+
+func unmarshalRootPointer(data []byte) (*RootPointer, error) {
+	if len(data) == 0 {
+		return nil, errors.New("truncated root pointer")
+	}
+	in := bytes.NewReader(data)
+	ptr := new(RootPointer)
+
+	if data[0] < 2 {
+		v1 := new(rootPointerV1)
+		if err := encoding.Unmarshal(in, v1); err != nil {
+			return nil, err
+		}
+		ptr.Version = v1.Version
+		ptr.Timestamp = v1.Timestamp
+		ptr.Pointer = v1.Pointer
+		ptr.Digest = v1.Digest
+		return ptr, nil
+	}
+	if data[0] == 2 {
+		v2 := new(rootPointerV2)
+		if err := encoding.Unmarshal(in, v2); err != nil {
+			return nil, err
+		}
+		ptr.Version = v2.Version
+		ptr.Timestamp = v2.Timestamp
+		ptr.Pointer = v2.Pointer
+		ptr.Index = v2.Index
+		ptr.Digest = v2.Digest
+		return ptr, nil
+	}
+	if err := encoding.Unmarshal(in, ptr); err != nil {
+		return nil, err
+	}
+	return ptr, nil
+}
@@ -0,0 +1,234 @@
+//
+// Copyright (c) 2026 Markku Rossi
+//
+// All rights reserved.
+//
+
+package zone
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/markkurossi/backup/lib/crypto/identity"
+	"github.com/markkurossi/backup/lib/crypto/shamir"
+	"github.com/markkurossi/backup/lib/encoding"
+)
+
+// The zone secret can be split into Shamir shares so that any
+// threshold number of share holders can open the zone together. Each
+// share is wrapped for its holder's public key and stored under the
+// shares namespace by the holder's key ID. The share set ID is
+// derived from the zone secret so the reconstructed secret can be
+// verified and the shares of an old zone secret are not mixed with
+// the current shares. The holder's public key is stored encrypted
+// with the zone secret so the shares can be re-split when the zone
+// is rekeyed.
+func (zone *Zone) shares() string {
+	return fmt.Sprintf("%s/shares", zone.Name)
+}
+
+func shareSetID(secret []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte("shares"))
+	return mac.Sum(nil)[:16]
+}
+
+// share defines the zone share object.
+type share struct {
+	Set       []byte
+	Threshold byte
+	Count     byte
+	PublicKey []byte
+	Data      []byte
+}
+
+// ShareHolder describes a holder of a zone secret share.
+type ShareHolder struct {
+	ID        string
+	Key       identity.PublicKey
+	Threshold int
+	Count     int
+}
+
+// Split splits the zone secret into shares for the keys so that any
+// threshold number of the keys can open the zone. The function
+// removes the previous shares of the zone. If exclusive is true, the
+// function also removes the zone identities and the recovery key so
+// that the zone can be opened only with the shares. The removed
+// identities know the current zone secret so the zone must be
+// rekeyed after an exclusive split.
+func (zone *Zone) Split(threshold int, keys []identity.PublicKey,
+	exclusive bool) error {
+
+	if zone.writeOnly {
+		return ErrWriteOnly
+	}
+	if threshold < 2 {
+		return fmt.Errorf("invalid share threshold %d: at least 2 shares "+
+			"must be needed to open the zone", threshold)
+	}
+	if len(keys) > 255 {
+		return fmt.Errorf("too many share holders: %d", len(keys))
+	}
+	seen := make(map[string]bool)
+	for _, key := range keys {
+		if identity.IsRecoveryID(key.ID()) {
+			return errors.New("recovery keys can't hold shares")
+		}
+		if seen[key.ID()] {
+			return fmt.Errorf("duplicate share holder %s", key.ID())
+		}
+		seen[key.ID()] = true
+	}
+	parts, err := shamir.Split(zone.secret, len(keys), threshold)
+	if err != nil {
+		return err
+	}
+	if err := zone.removeShares(); err != nil {
+		return err
+	}
+	set := shareSetID(zone.secret)
+	for idx, key := range keys {
+		pub, err := key.Marshal()
+		if err != nil {
+			return err
+		}
+		pub, err = zone.encrypt(pub)
+		if err != nil {
+			return err
+		}
+		encrypted, err := key.Encrypt(parts[idx])
+		if err != nil {
+			return err
+		}
+		data, err := encoding.Marshal(&share{
+			Set:       set,
+			Threshold: byte(threshold),
+			Count:     byte(len(keys)),
+			PublicKey: pub,
+			Data:      encrypted,
+		})
+		if err != nil {
+			return err
+		}
+		err = zone.Persistence.Set(zone.shares(), key.ID(), data)
+		if err != nil {
+			return err
+		}
+	}
+	if exclusive {
+		return zone.removeIdentities()
+	}
+	return nil
+}
+
+func (zone *Zone) removeShares() error {
+	// The zones without shares do not have the shares namespace.
+	kvs, err := zone.Persistence.GetAll(zone.shares())
+	if err != nil {
+		return nil
+	}
+	for id := range kvs {
+		if err := zone.Persistence.Delete(zone.shares(), id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ShareHolders returns the holders of the current zone secret shares
+// sorted by their IDs. The function requires a persistence storage
+// that supports GetAll.
+func (zone *Zone) ShareHolders() ([]*ShareHolder, error) {
+	kvs, err := zone.Persistence.GetAll(zone.shares())
+	if err != nil {
+		// No shares namespace.
+		return nil, nil
+	}
+	set := shareSetID(zone.secret)
+	var result []*ShareHolder
+	for id, data := range kvs {
+		s := new(share)
+		if err := encoding.Unmarshal(bytes.NewReader(data), s); err != nil {
+			return nil, fmt.Errorf("share %s: %s", id, err)
+		}
+		if !bytes.Equal(s.Set, set) {
+			continue
+		}
+		key, err := zone.decryptPublicKey(s.PublicKey)
+		if err != nil {
+			return nil, fmt.Errorf("share %s: %s", id, err)
+		}
+		result = append(result, &ShareHolder{
+			ID:        id,
+			Key:       key,
+			Threshold: int(s.Threshold),
+			Count:     int(s.Count),
+		})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ID < result[j].ID
+	})
+	return result, nil
+}
+
+// combineShares reconstructs the zone secret from the shares that the
+// keys can decrypt. The function returns the secret or the number of
+// shares found and the share threshold.
+func (zone *Zone) combineShares(keys []identity.PrivateKey) (
+	secret []byte, found, threshold int) {
+
+	type shareSet struct {
+		threshold int
+		parts     [][]byte
+	}
+	sets := make(map[string]*shareSet)
+	seen := make(map[string]bool)
+
+	for _, key := range keys {
+		// The same key can be in several agents.
+		if seen[key.ID()] {
+			continue
+		}
+		seen[key.ID()] = true
+		data, err := zone.Persistence.Get(zone.shares(), key.ID(), 0)
+		if err != nil {
+			continue
+		}
+		s := new(share)
+		if err := encoding.Unmarshal(bytes.NewReader(data), s); err != nil {
+			continue
+		}
+		part, err := key.Decrypt(s.Data)
+		if err != nil {
+			continue
+		}
+		set, ok := sets[string(s.Set)]
+		if !ok {
+			set = &shareSet{
+				threshold: int(s.Threshold),
+			}
+			sets[string(s.Set)] = set
+		}
+		set.parts = append(set.parts, part)
+	}
+
+	for id, set := range sets {
+		if len(set.parts) >= set.threshold {
+			result, err := shamir.Combine(set.parts[:set.threshold])
+			if err == nil && hmac.Equal(shareSetID(result), []byte(id)) {
+				return result, len(set.parts), set.threshold
+			}
+		}
+		if len(set.parts) > found {
+			found = len(set.parts)
+			threshold = set.threshold
+		}
+	}
+	return nil, found, threshold
+}
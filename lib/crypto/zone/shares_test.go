@@ -0,0 +1,113 @@
+//
+// Copyright (c) 2026 Markku Rossi
+//
+// All rights reserved.
+//
+
+package zone
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/markkurossi/backup/lib/crypto/identity"
+	"github.com/markkurossi/backup/lib/storage"
+)
+
+func TestShares(t *testing.T) {
+	z, keys := newTestZone(t, "owner")
+	root := z.Persistence
+	owner := keys[0]
+	var holders []identity.PrivateKey
+	var pubs []identity.PublicKey
+	for _, name := range []string{"alice", "bob", "carol"} {
+		key, err := identity.NewEd25519Key(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		holders = append(holders, key)
+		pubs = append(pubs, key.PublicKey())
+	}
+	if err := z.SetRootPointer(storage.EmptyID); err != nil {
+		t.Fatalf("SetRootPointer failed: %v", err)
+	}
+	if err := z.Split(2, pubs, false); err != nil {
+		t.Fatalf("Split failed: %v", err)
+	}
+	if err := z.Split(4, pubs, false); err == nil {
+		t.Errorf("Split with threshold over holder count succeeded")
+	}
+	if err := z.Split(1, pubs, false); err == nil {
+		t.Errorf("Split with threshold 1 succeeded")
+	}
+
+	_, err := Open(root, "test", holders[:1])
+	if !errors.Is(err, ErrNoKey) {
+		t.Fatalf("Open with one share: got %v, expected ErrNoKey", err)
+	}
+	for _, pair := range [][]identity.PrivateKey{
+		{holders[0], holders[1]},
+		{holders[1], holders[2]},
+		{holders[2], holders[0], holders[2]},
+	} {
+		if _, err := Open(root, "test", pair); err != nil {
+			t.Fatalf("Open with shares failed: %v", err)
+		}
+	}
+
+	// Rekey re-splits the new zone secret.
+	z, err = Open(root, "test", []identity.PrivateKey{owner})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	if err := z.Rekey(nil); err != nil {
+		t.Fatalf("Rekey failed: %v", err)
+	}
+	shares, err := z.ShareHolders()
+	if err != nil {
+		t.Fatalf("ShareHolders failed: %v", err)
+	}
+	if len(shares) != 3 {
+		t.Fatalf("got %d share holders, expected 3", len(shares))
+	}
+	for _, holder := range shares {
+		if holder.Threshold != 2 || holder.Count != 3 {
+			t.Errorf("share %s: got %d of %d, expected 2 of 3", holder.ID,
+				holder.Threshold, holder.Count)
+		}
+	}
+	z, err = Open(root, "test", holders[:2])
+	if err != nil {
+		t.Fatalf("Open with shares after rekey failed: %v", err)
+	}
+	if len(z.epochs) != 1 {
+		t.Errorf("got %d epochs, expected 1", len(z.epochs))
+	}
+
+	// The last identity can be revoked when the shares open the zone.
+	if err := z.Revoke(owner.ID()); err != nil {
+		t.Fatalf("Revoke failed: %v", err)
+	}
+	if err := z.AddIdentity(owner.PublicKey()); err != nil {
+		t.Fatalf("AddIdentity failed: %v", err)
+	}
+
+	// The exclusive split removes the zone identities so that no
+	// single key opens the zone.
+	if err := z.Split(2, pubs, true); err != nil {
+		t.Fatalf("exclusive Split failed: %v", err)
+	}
+	if err := z.Rekey(nil); err != nil {
+		t.Fatalf("Rekey without identities failed: %v", err)
+	}
+	for _, key := range keys {
+		_, err := Open(root, "test", []identity.PrivateKey{key})
+		if !errors.Is(err, ErrNoKey) {
+			t.Errorf("Open with %s: got %v, expected ErrNoKey", key.Name(),
+				err)
+		}
+	}
+	if _, err := Open(root, "test", holders[1:]); err != nil {
+		t.Fatalf("Open with shares after exclusive split failed: %v", err)
+	}
+}
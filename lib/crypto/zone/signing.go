@@ -0,0 +1,113 @@
+//
+// Copyright (c) 2026 Markku Rossi
+//
+// All rights reserved.
+//
+
+package zone
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/markkurossi/backup/lib/crypto/identity"
+	"github.com/markkurossi/backup/lib/tree"
+)
+
+// The zone HMACs are computed with the zone secret so anyone who can
+// open the zone can create valid snapshots and root pointers. The
+// writers sign the snapshots and root pointers with their identity
+// keys so the readers can verify that the snapshots were created by
+// trusted writers. The signatures are verified against a list of
+// trusted writer public keys that the reader provides.
+
+// SigningKey defines the identity key that signs the snapshots and
+// root pointers.
+type SigningKey interface {
+	ID() string
+	identity.Signer
+}
+
+// Signature verification errors.
+var (
+	ErrNotSigned = errors.New("not signed")
+	ErrUntrusted = errors.New("untrusted signer")
+)
+
+// SignSnapshot signs the snapshot with the zone's signing key. The
+// function does nothing if the zone does not have a signing key.
+func (zone *Zone) SignSnapshot(s *tree.Snapshot) error {
+	if zone.Signer == nil {
+		return nil
+	}
+	s.Signature.Signer = zone.Signer.ID()
+	data, err := s.SignedData()
+	if err != nil {
+		return err
+	}
+	s.Signature.Value, err = zone.Signer.Sign(data)
+	if err != nil {
+		return fmt.Errorf("failed to sign snapshot: %s", err)
+	}
+	return nil
+}
+
+// VerifySnapshot verifies the snapshot signature with the trusted
+// writers. The function returns the signer's key ID. The error is
+// ErrNotSigned for unsigned snapshots and ErrUntrusted if the signer
+// is not one of the writers.
+func VerifySnapshot(s *tree.Snapshot, writers []identity.PublicKey) (
+	string, error) {
+
+	if s.Version < 4 {
+		return "", ErrNotSigned
+	}
+	data, err := s.SignedData()
+	if err != nil {
+		return "", err
+	}
+	return s.Signature.Signer,
+		verify(s.Signature.Signer, data, s.Signature.Value, writers)
+}
+
+// VerifyRootPointer verifies the zone root pointer signature with
+// the trusted writers. The function returns the signer's key ID and
+// the error as VerifySnapshot.
+func (zone *Zone) VerifyRootPointer(writers []identity.PublicKey) (
+	string, error) {
+
+	ptr, err := zone.readRootPointer()
+	if err != nil {
+		return "", err
+	}
+	if ptr.Version < 3 {
+		return "", ErrNotSigned
+	}
+	data, err := ptr.signedData()
+	if err != nil {
+		return "", err
+	}
+	return ptr.Signer, verify(ptr.Signer, data, ptr.Signature, writers)
+}
+
+func verify(signer string, data, signature []byte,
+	writers []identity.PublicKey) error {
+
+	if len(signer) == 0 {
+		return ErrNotSigned
+	}
+	for _, writer := range writers {
+		if writer.ID() != signer {
+			continue
+		}
+		verifier, ok := writer.(identity.Verifier)
+		if !ok {
+			return fmt.Errorf("key %s can't verify signatures", signer)
+		}
+		if err := verifier.Verify(data, signature); err != nil {
+			return fmt.Errorf("invalid signature by %s: %s", signer, err)
+		}
+		return nil
+	}
+	return fmt.Errorf("%w %s", ErrUntrusted, signer)
+}
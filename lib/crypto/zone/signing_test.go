@@ -0,0 +1,92 @@
+//
+// Copyright (c) 2026 Markku Rossi
+//
+// All rights reserved.
+//
+
+package zone
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/markkurossi/backup/lib/crypto/identity"
+	"github.com/markkurossi/backup/lib/storage"
+	"github.com/markkurossi/backup/lib/tree"
+)
+
+func TestSigning(t *testing.T) {
+	z, keys := newTestZone(t, "alice")
+	root := z.Persistence
+	alice := keys[0]
+	bob, err := identity.NewRSAKey("bob", 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writers := []identity.PublicKey{alice.PublicKey()}
+
+	if err := z.SetRootPointer(storage.EmptyID); err != nil {
+		t.Fatalf("SetRootPointer failed: %v", err)
+	}
+	if _, err := z.VerifyRootPointer(writers); !errors.Is(err, ErrNotSigned) {
+		t.Errorf("unsigned root pointer: got %v, expected ErrNotSigned", err)
+	}
+
+	z.Signer = alice.(SigningKey)
+	s := tree.NewSnapshot()
+	s.Timestamp = 42
+	if err := z.SignSnapshot(s); err != nil {
+		t.Fatalf("SignSnapshot failed: %v", err)
+	}
+	data, err := s.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := z.Write(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := z.AddSnapshot(id, s); err != nil {
+		t.Fatalf("AddSnapshot failed: %v", err)
+	}
+
+	z, err = Open(root, "test", []identity.PrivateKey{alice})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	signer, err := z.VerifyRootPointer(writers)
+	if err != nil || signer != alice.ID() {
+		t.Errorf("VerifyRootPointer: %s %v", signer, err)
+	}
+	if _, err := VerifySnapshot(z.Head, writers); err != nil {
+		t.Errorf("VerifySnapshot failed: %v", err)
+	}
+	_, err = VerifySnapshot(z.Head, []identity.PublicKey{bob.PublicKey()})
+	if !errors.Is(err, ErrUntrusted) {
+		t.Errorf("untrusted signer: got %v, expected ErrUntrusted", err)
+	}
+	z.Head.Meta.Message = "forged"
+	if _, err := VerifySnapshot(z.Head, writers); err == nil {
+		t.Errorf("modified snapshot verified")
+	}
+
+	// Bob can open the zone but his root pointer is not trusted.
+	if err := z.AddIdentity(bob.PublicKey()); err != nil {
+		t.Fatalf("AddIdentity failed: %v", err)
+	}
+	z, err = Open(root, "test", []identity.PrivateKey{bob})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	z.Signer = bob.(SigningKey)
+	if err := z.SetRootPointer(z.HeadID); err != nil {
+		t.Fatalf("SetRootPointer failed: %v", err)
+	}
+	if _, err := z.VerifyRootPointer(writers); !errors.Is(err, ErrUntrusted) {
+		t.Errorf("untrusted root pointer: got %v, expected ErrUntrusted", err)
+	}
+	signer, err = z.VerifyRootPointer(append(writers, bob.PublicKey()))
+	if err != nil || signer != bob.ID() {
+		t.Errorf("VerifyRootPointer: %s %v", signer, err)
+	}
+}
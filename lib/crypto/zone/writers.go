@@ -0,0 +1,438 @@
+//
+// Copyright (c) 2026 Markku Rossi
+//
+// All rights reserved.
+//
+
+package zone
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/markkurossi/backup/lib/crypto/identity"
+	"github.com/markkurossi/backup/lib/encoding"
+	"github.com/markkurossi/backup/lib/persistence"
+	"github.com/markkurossi/backup/lib/storage"
+)
+
+// The write-only identities can add snapshots to the zone but they
+// can't decrypt the zone objects. Each writer has its own data key
+// that has the same structure as the zone secret. The data key is
+// wrapped for the writer's public key under the writer-identities
+// namespace and it is stored encrypted with the zone secret under
+// the writer-keys namespace so the full identities can decrypt the
+// writer's objects. The writer's object IDs are computed with the
+// writer's ID hash key so the writer can't compute the IDs of the
+// other objects or pre-empt them with its own objects.
+//
+// The writer does not read or update the root pointer or the
+// snapshot index. Instead, it adds its snapshots to the pending
+// namespace under the writer's ID. The full identities merge the
+// pending snapshots to the snapshot index when they open the zone
+// and they remove the pending snapshots when the index is saved. The
+// writers only create new objects and pending snapshots so the
+// repository storage can limit the writers to create-only access.
+//
+// The zone rekey creates new data keys for the writers. The previous
+// data keys are kept for decrypting the writers' objects but the
+// pending snapshots are merged only if they were written with the
+// current data key of an active writer.
+func (zone *Zone) writerIdentities() string {
+	return fmt.Sprintf("%s/writer-identities", zone.Name)
+}
+
+func (zone *Zone) writerKeys() string {
+	return fmt.Sprintf("%s/writer-keys", zone.Name)
+}
+
+func (zone *Zone) pending() string {
+	return fmt.Sprintf("%s/pending", zone.Name)
+}
+
+// writerKey defines the writer key object. The writer keys that were
+// written before the data key rotation end after Secret.
+type writerKey struct {
+	PublicKey []byte
+	Secret    []byte
+	Previous  [][]byte
+}
+
+// Writer describes a write-only identity of the zone.
+type Writer struct {
+	ID  string
+	Key identity.PublicKey
+	// Revoked tells if the writer was removed from the zone. The
+	// data keys of the removed writers are kept for decrypting their
+	// objects.
+	Revoked bool
+}
+
+// ErrWriteOnly is returned when a write-only identity attempts an
+// operation that requires a full identity.
+var ErrWriteOnly = errors.New("write-only identity")
+
+// WriteOnly tests if the zone was opened with a write-only identity.
+func (zone *Zone) WriteOnly() bool {
+	return zone.writeOnly
+}
+
+// AddWriter adds the key as a write-only identity for the zone.
+func (zone *Zone) AddWriter(key identity.PublicKey) error {
+	if zone.writeOnly {
+		return ErrWriteOnly
+	}
+	if identity.IsRecoveryID(key.ID()) {
+		return errors.New("recovery keys can't be writers")
+	}
+	secret := make([]byte, zone.suite.KeyLen())
+	if _, err := io.ReadFull(rand.Reader, secret); err != nil {
+		return err
+	}
+	pub, err := key.Marshal()
+	if err != nil {
+		return err
+	}
+	data, err := encoding.Marshal(&writerKey{
+		PublicKey: pub,
+		Secret:    secret,
+	})
+	if err != nil {
+		return err
+	}
+	data, err = zone.encrypt(data)
+	if err != nil {
+		return err
+	}
+	encrypted, err := key.Encrypt(secret)
+	if err != nil {
+		return err
+	}
+	err = zone.Persistence.Set(zone.writerKeys(), key.ID(), data)
+	if err != nil {
+		return err
+	}
+	return zone.Persistence.Set(zone.writerIdentities(), key.ID(), encrypted)
+}
+
+// RemoveWriter removes the write-only identity id from the zone. The
+// writer's data key is kept so its objects can be decrypted. The
+// writer's current pending snapshots are merged to the snapshot
+// index and its later pending snapshots are not merged. Since the
+// writer knows its data key, the repository storage must also revoke
+// the writer's access to the repository.
+func (zone *Zone) RemoveWriter(id string) error {
+	if zone.writeOnly {
+		return ErrWriteOnly
+	}
+	exists, err := zone.Persistence.Exists(zone.writerIdentities(), id)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("writer %s not found", id)
+	}
+	if err := zone.savePending(); err != nil {
+		return err
+	}
+	return zone.Persistence.Delete(zone.writerIdentities(), id)
+}
+
+// Writers returns the zone's write-only identities sorted by their
+// IDs. The function requires a persistence storage that supports
+// GetAll.
+func (zone *Zone) Writers() ([]*Writer, error) {
+	if zone.writeOnly {
+		return nil, ErrWriteOnly
+	}
+	keys, err := zone.readWriterKeys()
+	if err != nil {
+		return nil, err
+	}
+	active, err := zone.Persistence.GetAll(zone.writerIdentities())
+	if err != nil {
+		active = nil
+	}
+	var result []*Writer
+	for id, wk := range keys {
+		key, err := identity.UnmarshalPublicKey(wk.PublicKey)
+		if err != nil {
+			return nil, fmt.Errorf("writer %s: %s", id, err)
+		}
+		_, ok := active[id]
+		result = append(result, &Writer{
+			ID:      id,
+			Key:     key,
+			Revoked: !ok,
+		})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ID < result[j].ID
+	})
+	return result, nil
+}
+
+// WriterIDs returns the IDs of the write-only identities of the zone
+// name. The function does not need a key to open the zone but it
+// requires a persistence storage that supports GetAll.
+func WriterIDs(p persistence.Reader, name string) ([]string, error) {
+	ids, err := p.GetAll((&Zone{Name: name}).writerIdentities())
+	if err != nil {
+		return nil, nil
+	}
+	var result []string
+	for id := range ids {
+		result = append(result, id)
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+func (zone *Zone) decryptWriterKey(data []byte) (*writerKey, error) {
+	data, err := zone.decrypt(data)
+	if err != nil {
+		return nil, err
+	}
+	wk := new(writerKey)
+	err = encoding.Unmarshal(bytes.NewReader(data), wk)
+	if err == io.EOF && wk.Secret != nil && wk.Previous == nil {
+		// Writer key without previous data keys.
+		err = nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, secret := range append([][]byte{wk.Secret}, wk.Previous...) {
+		if len(secret) != zone.suite.KeyLen() {
+			return nil, fmt.Errorf("invalid writer key length: %d",
+				len(secret))
+		}
+	}
+	return wk, nil
+}
+
+// readWriterKeys reads and decrypts the writer keys of the zone.
+func (zone *Zone) readWriterKeys() (map[string]*writerKey, error) {
+	kvs, err := zone.Persistence.GetAll(zone.writerKeys())
+	if err != nil {
+		// No writers namespace.
+		return nil, nil
+	}
+	result := make(map[string]*writerKey)
+	for id, data := range kvs {
+		wk, err := zone.decryptWriterKey(data)
+		if err != nil {
+			return nil, fmt.Errorf("writer %s: %s", id, err)
+		}
+		result[id] = wk
+	}
+	return result, nil
+}
+
+// writeWriterKeys encrypts the writer keys with the current zone
+// secret. The zone rekey re-encrypts the writer keys because the
+// writer keys that are encrypted with a previous zone secret are not
+// trusted.
+func (zone *Zone) writeWriterKeys(keys map[string]*writerKey) error {
+	for id, wk := range keys {
+		data, err := encoding.Marshal(wk)
+		if err != nil {
+			return err
+		}
+		data, err = zone.encrypt(data)
+		if err != nil {
+			return err
+		}
+		if err := zone.Persistence.Set(zone.writerKeys(), id, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rekeyWriters creates new data keys for the active writers and
+// wraps them for the writers' public keys. The writer keys are
+// encrypted with the current zone secret.
+func (zone *Zone) rekeyWriters(keys map[string]*writerKey) error {
+	active, err := zone.Persistence.GetAll(zone.writerIdentities())
+	if err != nil {
+		active = nil
+	}
+	wrapped := make(map[string][]byte)
+	for id, wk := range keys {
+		if _, ok := active[id]; !ok {
+			continue
+		}
+		key, err := identity.UnmarshalPublicKey(wk.PublicKey)
+		if err != nil {
+			return fmt.Errorf("writer %s: %s", id, err)
+		}
+		secret := make([]byte, zone.suite.KeyLen())
+		if _, err := io.ReadFull(rand.Reader, secret); err != nil {
+			return err
+		}
+		encrypted, err := key.Encrypt(secret)
+		if err != nil {
+			return err
+		}
+		wk.Previous = append([][]byte{wk.Secret}, wk.Previous...)
+		wk.Secret = secret
+		wrapped[id] = encrypted
+	}
+	if err := zone.writeWriterKeys(keys); err != nil {
+		return err
+	}
+	for id, encrypted := range wrapped {
+		err := zone.Persistence.Set(zone.writerIdentities(), id, encrypted)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadWriterKeys loads the data keys of the zone writers for
+// decrypting the writers' objects.
+func (zone *Zone) loadWriterKeys() error {
+	keys, err := zone.readWriterKeys()
+	if err != nil {
+		return err
+	}
+	active, err := zone.Persistence.GetAll(zone.writerIdentities())
+	if err != nil {
+		active = nil
+	}
+	zone.writers = nil
+	for id, wk := range keys {
+		_, ok := active[id]
+		for idx, secret := range append([][]byte{wk.Secret}, wk.Previous...) {
+			e, err := newEpoch(secret, zone.suite)
+			if err != nil {
+				return err
+			}
+			e.writer = id
+			e.active = ok && idx == 0
+			zone.writers = append(zone.writers, e)
+		}
+	}
+	return nil
+}
+
+// writerEpoch tests if the keys e are the data keys of a writer.
+func (zone *Zone) writerEpoch(e *epoch) bool {
+	for _, w := range zone.writers {
+		if w == e {
+			return true
+		}
+	}
+	return false
+}
+
+// unlockWriter opens the zone with the write-only identity key.
+func (zone *Zone) unlockWriter(keys []identity.PrivateKey) (*Zone, bool,
+	error) {
+
+	for _, key := range keys {
+		data, err := zone.Persistence.Get(zone.writerIdentities(), key.ID(), 0)
+		if err != nil {
+			continue
+		}
+		secret, err := key.Decrypt(data)
+		if err != nil {
+			continue
+		}
+		if err := zone.init(secret, suite); err != nil {
+			return nil, false, err
+		}
+		zone.writeOnly = true
+		zone.writerID = key.ID()
+		return zone, true, nil
+	}
+	return nil, false, nil
+}
+
+// addPending adds the snapshot id to the zone's pending snapshots.
+// The pending snapshot records the ID of the writer.
+func (zone *Zone) addPending(id storage.ID) error {
+	return zone.Persistence.Set(zone.pending(), id.ToFullString(),
+		[]byte(zone.writerID))
+}
+
+// mergePending adds the pending snapshots of the writers to the
+// zone's snapshot index. The writers control the timestamps of their
+// snapshots so the merged snapshots don't replace the zone head. The
+// merged index is saved when the zone index is next updated. The
+// pending snapshots that can't be read are kept pending. The pending
+// snapshots that were not written with the current data key of the
+// recording writer are removed without merging them.
+func (zone *Zone) mergePending() error {
+	zone.merged = nil
+	kvs, err := zone.Persistence.GetAll(zone.pending())
+	if err != nil || len(kvs) == 0 {
+		return nil
+	}
+	index, err := zone.loadIndex()
+	if err != nil {
+		return err
+	}
+	for key, writer := range kvs {
+		id, err := storage.IDFromString(key)
+		if err != nil {
+			continue
+		}
+		var found bool
+		for _, entry := range index.Snapshots {
+			if entry.ID.Equal(id) {
+				found = true
+				break
+			}
+		}
+		if found {
+			zone.merged = append(zone.merged, key)
+			continue
+		}
+		snapshot, e, err := zone.readSnapshot(id)
+		if err != nil {
+			fmt.Printf("Failed to read pending snapshot %s: %s\n", id, err)
+			continue
+		}
+		if !e.active || (len(writer) > 0 && string(writer) != e.writer) {
+			fmt.Printf("Pending snapshot %s not written by an active writer\n",
+				id)
+			zone.merged = append(zone.merged, key)
+			continue
+		}
+		index.Add(id, snapshot)
+		zone.merged = append(zone.merged, key)
+	}
+	zone.Index = index
+	return nil
+}
+
+// savePending merges the pending snapshots and saves the snapshot
+// index if any pending snapshots were merged or rejected.
+func (zone *Zone) savePending() error {
+	if err := zone.mergePending(); err != nil {
+		return err
+	}
+	if len(zone.merged) == 0 {
+		return nil
+	}
+	return zone.saveIndex(zone.Index)
+}
+
+// removeMerged removes the pending snapshots that were merged to the
+// saved snapshot index or rejected.
+func (zone *Zone) removeMerged() error {
+	for _, key := range zone.merged {
+		if err := zone.Persistence.Delete(zone.pending(), key); err != nil {
+			return err
+		}
+	}
+	zone.merged = nil
+	return nil
+}
@@ -0,0 +1,220 @@
+//
+// Copyright (c) 2026 Markku Rossi
+//
+// All rights reserved.
+//
+
+package zone
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/markkurossi/backup/lib/crypto/identity"
+	"github.com/markkurossi/backup/lib/persistence"
+	"github.com/markkurossi/backup/lib/storage"
+	"github.com/markkurossi/backup/lib/tree"
+)
+
+// newTestZone creates a zone and Ed25519 keys for the names. The keys
+// are added as the zone identities.
+func newTestZone(t *testing.T, names ...string) (*Zone,
+	[]identity.PrivateKey) {
+
+	root, err := persistence.OpenFilesystem(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	z, err := Create(root, "test")
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	var keys []identity.PrivateKey
+	for _, name := range names {
+		key, err := identity.NewEd25519Key(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := z.AddIdentity(key.PublicKey()); err != nil {
+			t.Fatalf("AddIdentity failed: %v", err)
+		}
+		keys = append(keys, key)
+	}
+	return z, keys
+}
+
+func writeSnapshot(t *testing.T, z *Zone, timestamp int64) storage.ID {
+	s := tree.NewSnapshot()
+	s.Timestamp = timestamp
+	data, err := s.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := z.Write(data)
+	if err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if err := z.AddSnapshot(id, s); err != nil {
+		t.Fatalf("AddSnapshot failed: %v", err)
+	}
+	return id
+}
+
+func TestWriters(t *testing.T) {
+	z, keys := newTestZone(t, "owner")
+	root := z.Persistence
+	owner := keys[0]
+	server, err := identity.NewEd25519Key("server")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	secret := []byte("owner data")
+	secretID, err := z.Write(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ownerID := writeSnapshot(t, z, 1)
+	if err := z.AddWriter(server.PublicKey()); err != nil {
+		t.Fatalf("AddWriter failed: %v", err)
+	}
+
+	// The writer can add snapshots but it can't read the zone.
+	w, err := Open(root, "test", []identity.PrivateKey{server})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	if !w.WriteOnly() || w.Head != nil {
+		t.Fatalf("writer opened full zone")
+	}
+	if _, err := w.Read(secretID); err == nil {
+		t.Errorf("writer decrypted zone object")
+	}
+	id, err := w.Write(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id.Equal(secretID) {
+		t.Errorf("writer computed zone object ID")
+	}
+	// The writer's timestamp is newer than the owner's next snapshot.
+	writerID := writeSnapshot(t, w, 100)
+	if err := w.AddIdentity(server.PublicKey()); !errors.Is(err, ErrWriteOnly) {
+		t.Errorf("AddIdentity: got %v, expected ErrWriteOnly", err)
+	}
+	if err := w.SetRootPointer(writerID); !errors.Is(err, ErrWriteOnly) {
+		t.Errorf("SetRootPointer: got %v, expected ErrWriteOnly", err)
+	}
+
+	// A pending snapshot that can't be read stays pending.
+	missing := storage.NewID(make([]byte, 32))
+	if err := w.addPending(missing); err != nil {
+		t.Fatal(err)
+	}
+
+	// The owner sees the writer's snapshot but it does not replace
+	// the owner's head.
+	z, err = Open(root, "test", []identity.PrivateKey{owner})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	if z.WriteOnly() || !z.HeadID.Equal(ownerID) {
+		t.Fatalf("pending snapshot replaced head: head %s", z.HeadID)
+	}
+	if len(z.Index.Snapshots) != 2 ||
+		!z.Index.Snapshots[0].ID.Equal(writerID) ||
+		!z.Index.Snapshots[1].ID.Equal(ownerID) {
+		t.Fatalf("unexpected index: %v", z.Index.Snapshots)
+	}
+	data, err := z.Read(id)
+	if err != nil || string(data) != string(secret) {
+		t.Errorf("owner failed to read writer object: %v", err)
+	}
+
+	// Saving the index removes the merged pending snapshots and the
+	// owner's snapshot becomes the head.
+	nextID := writeSnapshot(t, z, 3)
+	if len(z.merged) != 0 {
+		t.Errorf("merged pending snapshots not removed")
+	}
+	if !z.HeadID.Equal(nextID) {
+		t.Errorf("AddSnapshot: head %s, expected %s", z.HeadID, nextID)
+	}
+	ok, err := z.Persistence.Exists(z.pending(), missing.ToFullString())
+	if err != nil || !ok {
+		t.Errorf("unreadable pending snapshot removed: %v", err)
+	}
+	z, err = Open(root, "test", []identity.PrivateKey{owner})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	if len(z.Index.Snapshots) != 3 || len(z.merged) != 0 {
+		t.Errorf("unexpected index: %v", z.Index.Snapshots)
+	}
+	if !z.HeadID.Equal(nextID) {
+		t.Errorf("Open: head %s, expected %s", z.HeadID, nextID)
+	}
+	if err := z.ForgetSnapshot(nextID); err != nil {
+		t.Fatalf("ForgetSnapshot failed: %v", err)
+	}
+	if !z.HeadID.Equal(ownerID) {
+		t.Errorf("ForgetSnapshot: head %s, expected %s", z.HeadID, ownerID)
+	}
+	if _, err := z.RebuildIndex(); err != nil {
+		t.Fatalf("RebuildIndex failed: %v", err)
+	}
+	if !z.HeadID.Equal(nextID) {
+		t.Errorf("RebuildIndex: head %s, expected %s", z.HeadID, nextID)
+	}
+
+	// The rekey rotates the writer keys. The pending snapshots of
+	// the previous writer key are not merged.
+	if err := z.Rekey(nil); err != nil {
+		t.Fatalf("Rekey failed: %v", err)
+	}
+	writers, err := z.Writers()
+	if err != nil || len(writers) != 1 || writers[0].ID != server.ID() {
+		t.Fatalf("Writers: %v %v", writers, err)
+	}
+	oldID := writeSnapshot(t, w, 4)
+	w, err = Open(root, "test", []identity.PrivateKey{server})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	rekeyedID := writeSnapshot(t, w, 5)
+	z, err = Open(root, "test", []identity.PrivateKey{owner})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	if len(z.Index.Snapshots) != 4 ||
+		!z.Index.Snapshots[0].ID.Equal(writerID) ||
+		!z.Index.Snapshots[1].ID.Equal(rekeyedID) {
+		t.Fatalf("unexpected index: %v", z.Index.Snapshots)
+	}
+	if _, err := z.Read(oldID); err != nil {
+		t.Errorf("failed to read previous writer key object: %v", err)
+	}
+
+	// The pending snapshots of the removed writers are not merged.
+	if err := z.RemoveWriter(server.ID()); err != nil {
+		t.Fatalf("RemoveWriter failed: %v", err)
+	}
+	if _, err := Open(root, "test", []identity.PrivateKey{server}); err == nil {
+		t.Errorf("removed writer opened zone")
+	}
+	writeSnapshot(t, w, 6)
+	z, err = Open(root, "test", []identity.PrivateKey{owner})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	if len(z.Index.Snapshots) != 4 {
+		t.Errorf("unexpected index: %v", z.Index.Snapshots)
+	}
+	if _, err := z.Read(id); err != nil {
+		t.Errorf("failed to read removed writer's object: %v", err)
+	}
+	writers, err = z.Writers()
+	if err != nil || len(writers) != 1 || !writers[0].Revoked {
+		t.Errorf("Writers: %v %v", writers, err)
+	}
+}
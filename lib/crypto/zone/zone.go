@@ -9,11 +9,8 @@ package zone
 import (
 	"bytes"
 	"compress/zlib"
-	"crypto/aes"
 	"crypto/cipher"
-	"crypto/hmac"
 	"crypto/rand"
-	"crypto/sha256"
 	"encoding/hex"
 	"errors"
 	"fmt"
@@ -23,7 +20,6 @@ import (
 	"time"
 
 	"github.com/markkurossi/backup/lib/crypto/identity"
-	"github.com/markkurossi/backup/lib/encoding"
 	"github.com/markkurossi/backup/lib/persistence"
 	"github.com/markkurossi/backup/lib/storage"
 	"github.com/markkurossi/backup/lib/tree"
@@ -41,13 +37,31 @@ type Zone struct {
 	Persistence persistence.Accessor
 	Head        *tree.Snapshot
 	HeadID      storage.ID
+	Index       *tree.SnapshotIndex
+	IndexID     storage.ID
 	idHash      hash.Hash
+	newIDHash   func() hash.Hash
 	secret      []byte
 	suite       Suite
 	cipher      cipher.Block
 	hmac        hash.Hash
+	newHMAC     func() hash.Hash
+	epochs      []*epoch
+	secrets     [][]byte
+	writers     []*epoch
+	writeOnly   bool
+	writerID    string
+	merged      []string
+	// logSeq is the sequence number of the last root pointer log
+	// entry if logSeqValid is true.
+	logSeq      int
+	logSeqValid bool
+	Signer      SigningKey
+	Compress    bool
 	Written     uint64
 	Saved       uint64
+	Objects     uint64
+	Dedup       uint64
 }
 
 func (zone *Zone) identities() string {
@@ -61,25 +75,52 @@ func (zone *Zone) objectNames(id storage.ID) (string, string) {
 	return ns, key
 }
 
-// AddIdentity adds identity key for the zone.
-func (zone *Zone) AddIdentity(key identity.PublicKey) error {
-	encrypted, err := key.Encrypt(zone.secret)
-	if err != nil {
-		return err
-	}
-	return zone.Persistence.Set(zone.identities(), key.ID(), encrypted)
+// Read implements the storage.Reader interface. The function
+// verifies that the object content matches its ID. The function is
+// safe for concurrent use.
+func (zone *Zone) Read(id storage.ID) ([]byte, error) {
+	data, _, err := zone.read(id)
+	return data, err
 }
 
-// Read implements the storage.Reader interface.
-func (zone *Zone) Read(id storage.ID) ([]byte, error) {
+// read reads the object id and returns its data and the keys that
+// encrypted it.
+func (zone *Zone) read(id storage.ID) ([]byte, *epoch, error) {
 	namespace, key := zone.objectNames(id)
 
 	data, err := zone.Persistence.Get(namespace, key, 0)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+
+	data, e, err := zone.decryptObject(data)
+	if err != nil {
+		return nil, nil, err
 	}
+	if err := e.verifyID(id, data); err != nil {
+		return nil, nil, err
+	}
+	return data, e, nil
+}
+
+// readSnapshot reads the snapshot id and returns the snapshot and
+// the keys that encrypted it.
+func (zone *Zone) readSnapshot(id storage.ID) (*tree.Snapshot, *epoch,
+	error) {
 
-	return zone.decrypt(data)
+	data, e, err := zone.read(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	element, err := tree.Deserialize(data, zone)
+	if err != nil {
+		return nil, nil, err
+	}
+	snapshot, ok := element.(*tree.Snapshot)
+	if !ok {
+		return nil, nil, fmt.Errorf("ID %s is not a snapshot", id)
+	}
+	return snapshot, e, nil
 }
 
 // Write implements the storage.Writer interface.
@@ -96,6 +137,7 @@ func (zone *Zone) Write(data []byte) (id storage.ID, err error) {
 		return id, err
 	}
 	if exists {
+		zone.Dedup += uint64(len(data))
 		return id, nil
 	}
 
@@ -109,49 +151,55 @@ func (zone *Zone) Write(data []byte) (id storage.ID, err error) {
 	if err != nil {
 		return
 	}
+	zone.Objects++
 
 	return
 }
 
+// StoredSize returns the size of the encrypted object id in the
+// persistence storage.
+func (zone *Zone) StoredSize(id storage.ID) (int64, error) {
+	namespace, key := zone.objectNames(id)
+	return zone.Persistence.Size(namespace, key)
+}
+
 func (zone *Zone) init(secret []byte, suite Suite) error {
 	if len(secret) != suite.KeyLen() {
 		return fmt.Errorf("invalid zone key length: %d vs %d", len(secret),
 			zone.suite.KeyLen())
 	}
+	e, err := newEpoch(secret, suite)
+	if err != nil {
+		return err
+	}
 	zone.secret = secret
 	zone.suite = suite
-
-	split1 := suite.IDHashKeyLen()
-	split2 := split1 + suite.CipherKeyLen()
-
-	switch suite {
-	case AES256CBCHMACSHA256:
-		zone.idHash = hmac.New(sha256.New, secret[:split1])
-
-		block, err := aes.NewCipher(secret[split1:split2])
-		if err != nil {
-			return err
-		}
-		zone.cipher = block
-
-		zone.hmac = hmac.New(sha256.New, secret[split2:])
-
-	default:
-		return fmt.Errorf("unsupported suite: %s", suite)
-	}
+	zone.idHash = e.idHash
+	zone.newIDHash = e.newIDHash
+	zone.cipher = e.cipher
+	zone.newHMAC = e.newHMAC
+	zone.hmac = zone.newHMAC()
 
 	return nil
 }
 
-// SetRootPointer sets the root pointer of the zone to id.
+// SetRootPointer sets the root pointer of the zone to id. The root
+// pointer also references the zone's snapshot index IndexID.
 func (zone *Zone) SetRootPointer(id storage.ID) error {
+	if zone.writeOnly {
+		return ErrWriteOnly
+	}
 	pointer := &RootPointer{
-		Version:   1,
+		Version:   RootPointerVersion,
 		Timestamp: time.Now().UnixNano(),
 		Pointer:   id,
+		Index:     zone.IndexID,
+	}
+	if zone.Signer != nil {
+		pointer.Signer = zone.Signer.ID()
 	}
 
-	input, err := encoding.Marshal(pointer)
+	input, err := pointer.signedData()
 	if err != nil {
 		return err
 	}
@@ -160,7 +208,14 @@ func (zone *Zone) SetRootPointer(id storage.ID) error {
 	zone.hmac.Write(input)
 	pointer.Digest = zone.hmac.Sum(nil)
 
-	final, err := encoding.Marshal(pointer)
+	if zone.Signer != nil {
+		pointer.Signature, err = zone.Signer.Sign(input)
+		if err != nil {
+			return fmt.Errorf("failed to sign root pointer: %s", err)
+		}
+	}
+
+	final, err := pointer.marshal()
 	if err != nil {
 		return err
 	}
@@ -173,52 +228,96 @@ func (zone *Zone) SetRootPointer(id storage.ID) error {
 	// Second copy `rootDistance' away from the first copy.
 	data = append(data, final...)
 
-	return zone.Persistence.Set(zone.Name, rootPointer, data)
+	err = zone.Persistence.Set(zone.Name, rootPointer, data)
+	if err != nil {
+		return err
+	}
+	return zone.appendLog(final)
 }
 
+// ErrRootPointer is returned when the zone root pointer or the head
+// snapshot can't be read.
+var ErrRootPointer = errors.New("root pointer damaged")
+
 func (zone *Zone) getHead() error {
-	data, err := zone.Persistence.Get(zone.Name, rootPointer,
-		persistence.NoCache)
+	ptr, err := zone.readRootPointer()
 	if err != nil {
-		return zone.bruteForceRootPointer()
+		// Fall back to the root pointer log.
+		var logErr error
+		ptr, _, logErr = zone.lastLogPointer()
+		if logErr != nil {
+			return fmt.Errorf("%w: %s, log: %s", ErrRootPointer, err, logErr)
+		}
+		fmt.Printf("Root pointer damaged, using root pointer log\n")
+	}
+	err = zone.setHead(ptr)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrRootPointer, err)
 	}
-	in := bytes.NewReader(data)
+	return nil
+}
 
-	ptr1 := new(RootPointer)
-	err1 := encoding.Unmarshal(in, ptr1)
+// readRootPointer reads the root pointer copies and returns the
+// newest valid copy.
+func (zone *Zone) readRootPointer() (*RootPointer, error) {
+	ptrs, errs := zone.rootPointerCopies()
+
+	if errs[0] == nil && errs[1] == nil {
+		if ptrs[0].Timestamp > ptrs[1].Timestamp {
+			return ptrs[0], nil
+		}
+		return ptrs[1], nil
+	} else if errs[0] == nil {
+		return ptrs[0], nil
+	} else if errs[1] == nil {
+		return ptrs[1], nil
+	}
+	return nil, errs[0]
+}
 
-	ptr2 := new(RootPointer)
-	var err2 error
+// rootPointerCopies reads and verifies both root pointer copies.
+func (zone *Zone) rootPointerCopies() ([2]*RootPointer, [2]error) {
+	var ptrs [2]*RootPointer
+	var errs [2]error
+
+	data, err := zone.Persistence.Get(zone.Name, rootPointer,
+		persistence.NoCache)
+	if err != nil {
+		errs[0] = err
+		errs[1] = err
+		return ptrs, errs
+	}
+	ptrs[0], errs[0] = unmarshalRootPointer(data)
 
 	if len(data) > rootDistance {
-		in = bytes.NewReader(data[rootDistance:])
-		err2 = encoding.Unmarshal(in, ptr2)
+		ptrs[1], errs[1] = unmarshalRootPointer(data[rootDistance:])
 	} else {
-		err2 = io.EOF
+		errs[1] = io.EOF
 	}
 
-	if err1 == nil {
-		err1 = zone.checkRootPointer(ptr1)
-	}
-	if err2 == nil {
-		err2 = zone.checkRootPointer(ptr2)
+	for i := 0; i < 2; i++ {
+		if errs[i] == nil {
+			errs[i] = zone.checkRootPointer(ptrs[i], -1)
+		}
 	}
+	return ptrs, errs
+}
 
-	var id storage.ID
-
-	if err1 == nil && err2 == nil {
-		if ptr1.Timestamp > ptr2.Timestamp {
-			id = ptr1.Pointer
-		} else {
-			id = ptr2.Pointer
+// setHead sets the zone's head snapshot and snapshot index from the
+// root pointer.
+func (zone *Zone) setHead(ptr *RootPointer) error {
+	zone.Head = nil
+	zone.HeadID = storage.EmptyID
+	zone.Index = nil
+	zone.IndexID = storage.EmptyID
+
+	if !ptr.Index.Undefined() {
+		if err := zone.readIndex(ptr.Index); err != nil {
+			fmt.Printf("Failed to read snapshot index '%s': %s\n",
+				ptr.Index, err)
 		}
-	} else if err1 == nil {
-		id = ptr1.Pointer
-	} else if err2 == nil {
-		id = ptr2.Pointer
-	} else {
-		return zone.bruteForceRootPointer()
 	}
+	id := ptr.Pointer
 	if id.Undefined() {
 		// Empty backup object tree.
 		return nil
@@ -226,8 +325,7 @@ func (zone *Zone) getHead() error {
 
 	element, err := tree.DeserializeID(id, zone)
 	if err != nil {
-		fmt.Printf("Failed to deserialize snapshot '%s': %s\n", id, err)
-		return zone.bruteForceRootPointer()
+		return fmt.Errorf("failed to deserialize snapshot '%s': %s", id, err)
 	}
 	head, ok := element.(*tree.Snapshot)
 	if !ok {
@@ -240,30 +338,36 @@ func (zone *Zone) getHead() error {
 	return nil
 }
 
-func (zone *Zone) checkRootPointer(ptr *RootPointer) error {
-	digest := ptr.Digest
-	ptr.Digest = nil
-
-	input, err := encoding.Marshal(ptr)
+// checkRootPointer verifies the root pointer with the current zone
+// secret. The seq specifies the root pointer log entry sequence
+// number or -1 for the root pointer copies. The root pointer log
+// entries that were written before the zone was rekeyed are also
+// verified with the previous zone secrets.
+func (zone *Zone) checkRootPointer(ptr *RootPointer, seq int) error {
+	input, err := ptr.signedData()
 	if err != nil {
 		return err
 	}
 
-	zone.hmac.Reset()
-	zone.hmac.Write(input)
-
-	computed := zone.hmac.Sum(nil)
-
-	if !bytes.Equal(digest, computed) {
-		return errors.New("Invalid root pointer integrity check value")
+	macs := []func() hash.Hash{zone.newHMAC}
+	for _, e := range zone.epochs {
+		if seq >= 0 && int64(seq) < e.logSeq {
+			macs = append(macs, e.newHMAC)
+		}
 	}
-
-	return nil
+	for _, newHMAC := range macs {
+		mac := newHMAC()
+		mac.Write(input)
+		if bytes.Equal(ptr.Digest, mac.Sum(nil)) {
+			return nil
+		}
+	}
+	return errors.New("Invalid root pointer integrity check value")
 }
 
-func (zone *Zone) bruteForceRootPointer() error {
-	var best *tree.Snapshot
-	var bestID storage.ID
+// scanSnapshots scans all zone objects and calls fn for each
+// snapshot object.
+func (zone *Zone) scanSnapshots(fn func(id storage.ID, s *tree.Snapshot)) {
 	var buf [2]byte
 
 	for i := 0; i < 256; i++ {
@@ -278,7 +382,7 @@ func (zone *Zone) bruteForceRootPointer() error {
 			}
 
 			for k, v := range kvs {
-				data, err := zone.decrypt(v)
+				data, e, err := zone.decryptObject(v)
 				if err != nil {
 					continue
 				}
@@ -287,51 +391,42 @@ func (zone *Zone) bruteForceRootPointer() error {
 					continue
 				}
 				snapshot, ok := element.(*tree.Snapshot)
-				if ok {
-					if best == nil || snapshot.Timestamp > best.Timestamp {
-
-						idData := []byte{byte(i), byte(j)}
-						suffix, err := hex.DecodeString(k)
-						if err != nil {
-							continue
-						}
-						idData = append(idData, suffix...)
-
-						best = snapshot
-						bestID = storage.NewID(idData)
-					}
+				if !ok {
+					continue
+				}
+				suffix, err := hex.DecodeString(k)
+				if err != nil {
+					continue
+				}
+				idData := []byte{byte(i), byte(j)}
+				idData = append(idData, suffix...)
+				id := storage.NewID(idData)
+
+				if e.verifyID(id, data) != nil ||
+					!zone.trustedSnapshot(e, id) {
+					continue
 				}
+				fn(id, snapshot)
 			}
 		}
 	}
-
-	if best == nil {
-		return fmt.Errorf("no root pointer found from object store")
-	}
-
-	zone.Head = best
-	zone.HeadID = bestID
-
-	err := zone.SetRootPointer(bestID)
-	if err != nil {
-		fmt.Printf("Failed to set root pointer: %s\n", err)
-	}
-
-	return nil
 }
 
 func (zone *Zone) encrypt(orig []byte) ([]byte, error) {
 	zone.Written += uint64(len(orig))
 
 	// Does it compress?
-	var b bytes.Buffer
-	z := zlib.NewWriter(&b)
-	z.Write(orig)
-	z.Close()
+	var compressed []byte
+	if zone.Compress {
+		var b bytes.Buffer
+		z := zlib.NewWriter(&b)
+		z.Write(orig)
+		z.Close()
+		compressed = b.Bytes()
+	}
 
-	compressed := b.Bytes()
 	var data []byte
-	if len(compressed) < len(orig) {
+	if zone.Compress && len(compressed) < len(orig) {
 		zone.Saved += uint64(len(orig) - len(compressed))
 		data = append(data, 1)
 		data = append(data, compressed...)
@@ -375,10 +470,47 @@ func (zone *Zone) encrypt(orig []byte) ([]byte, error) {
 	return zone.hmac.Sum(input), nil
 }
 
+// decrypt decrypts the zone metadata with the current zone
+// secret. The function is safe for concurrent use.
 func (zone *Zone) decrypt(data []byte) ([]byte, error) {
+	return decrypt(zone.cipher, zone.newHMAC(), data)
+}
+
+// decryptObject decrypts the object data. The object is decrypted
+// with the current zone secret, with the previous zone secrets if
+// the object was written before the zone was rekeyed, or with the
+// writer data keys if the object was written by a write-only
+// identity. The function returns the keys that decrypted the
+// object. The function is safe for concurrent use.
+func (zone *Zone) decryptObject(data []byte) ([]byte, *epoch, error) {
+	e := &epoch{
+		newIDHash: zone.newIDHash,
+		cipher:    zone.cipher,
+		newHMAC:   zone.newHMAC,
+	}
+	result, err := decrypt(e.cipher, e.newHMAC(), data)
+	for i := 0; err == errHMAC && i < len(zone.epochs); i++ {
+		e = zone.epochs[i]
+		result, err = decrypt(e.cipher, e.newHMAC(), data)
+	}
+	for i := 0; err == errHMAC && i < len(zone.writers); i++ {
+		e = zone.writers[i]
+		result, err = decrypt(e.cipher, e.newHMAC(), data)
+	}
+	return result, e, err
+}
+
+var errHMAC = errors.New("HMAC mismatch")
+
+// decrypt decrypts the data with the cipher and verifies its
+// integrity with mac. The function returns errHMAC if the integrity
+// check fails and the data is not modified in that case.
+func decrypt(block cipher.Block, mac hash.Hash, data []byte) ([]byte,
+	error) {
+
 	// Sanity check input length.
-	blockSize := zone.cipher.BlockSize()
-	hmacLen := zone.hmac.Size()
+	blockSize := block.BlockSize()
+	hmacLen := mac.Size()
 	if len(data) <= blockSize+hmacLen {
 		// Zero-length data is impossible because of minimum padding
 		// up to next block size (+1 for padding length).
@@ -390,18 +522,17 @@ func (zone *Zone) decrypt(data []byte) ([]byte, error) {
 	}
 	split := len(data) - hmacLen
 	encrypted := data[:split]
-	hmac := data[split:]
+	digest := data[split:]
 
 	// Check HMAC.
-	zone.hmac.Reset()
-	zone.hmac.Write(encrypted)
-	computed := zone.hmac.Sum(nil)
-	if !bytes.Equal(hmac, computed) {
-		return nil, fmt.Errorf("HMAC mismatch")
+	mac.Write(encrypted)
+	computed := mac.Sum(nil)
+	if !bytes.Equal(digest, computed) {
+		return nil, errHMAC
 	}
 
 	// Decrypt data.
-	cbc := cipher.NewCBCDecrypter(zone.cipher, encrypted[:blockSize])
+	cbc := cipher.NewCBCDecrypter(block, encrypted[:blockSize])
 	toDecrypt := encrypted[blockSize:]
 	cbc.CryptBlocks(toDecrypt, toDecrypt)
 
@@ -434,6 +565,7 @@ func newZone(name string, persistence persistence.Accessor) *Zone {
 	return &Zone{
 		Name:        name,
 		Persistence: persistence,
+		Compress:    true,
 	}
 }
 
@@ -452,10 +584,44 @@ func Create(persistence persistence.Accessor, name string) (*Zone, error) {
 	return zone, nil
 }
 
-// Open opens the zone name from the persistence.
+// Open opens the zone name from the persistence. If the zone is
+// opened with a write-only identity, the zone does not have the
+// head snapshot or the snapshot index. Otherwise, the writers'
+// pending snapshots are merged to the zone's snapshot index.
 func Open(persistence persistence.Accessor, name string,
 	keys []identity.PrivateKey) (*Zone, error) {
 
+	zone, err := Unlock(persistence, name, keys)
+	if err != nil {
+		return nil, err
+	}
+	if zone.writeOnly {
+		return zone, nil
+	}
+
+	// Get head snapshot.
+	err = zone.getHead()
+	if err != nil {
+		return nil, err
+	}
+	err = zone.mergePending()
+	if err != nil {
+		return nil, err
+	}
+
+	return zone, nil
+}
+
+// ErrNoKey is returned when none of the keys can open the zone.
+var ErrNoKey = errors.New("no key to open zone")
+
+// Unlock opens the zone name from the persistence without reading
+// its head snapshot. The keys are tried as the zone identities, as
+// the zone secret share holders, and finally as the write-only
+// identities of the zone.
+func Unlock(persistence persistence.Accessor, name string,
+	keys []identity.PrivateKey) (*Zone, error) {
+
 	zone := newZone(name, persistence)
 
 	// Do we have an identity to open the zone?
@@ -468,19 +634,40 @@ func Open(persistence persistence.Accessor, name string,
 		if err != nil {
 			continue
 		}
-		err = zone.init(secret, suite)
-		if err != nil {
-			return nil, err
-		}
+		return zone.unlock(secret)
+	}
 
-		// Get head snapshot.
-		err = zone.getHead()
-		if err != nil {
-			return nil, err
-		}
+	// Can the keys open the zone with the zone secret shares?
+	secret, found, threshold := zone.combineShares(keys)
+	if secret != nil {
+		return zone.unlock(secret)
+	}
 
-		return zone, nil
+	// Is any of the keys a write-only identity?
+	writer, ok, err := zone.unlockWriter(keys)
+	if err != nil {
+		return nil, err
 	}
+	if ok {
+		return writer, nil
+	}
+	if found > 0 {
+		return nil, fmt.Errorf("%w '%s': %d of %d shares", ErrNoKey, name,
+			found, threshold)
+	}
+
+	return nil, fmt.Errorf("%w '%s'", ErrNoKey, name)
+}
 
-	return nil, fmt.Errorf("no key to open zone '%s'", name)
+func (zone *Zone) unlock(secret []byte) (*Zone, error) {
+	if err := zone.init(secret, suite); err != nil {
+		return nil, err
+	}
+	if err := zone.loadKeyring(); err != nil {
+		return nil, err
+	}
+	if err := zone.loadWriterKeys(); err != nil {
+		return nil, err
+	}
+	return zone, nil
 }
@@ -11,7 +11,9 @@ import (
 	"io"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/markkurossi/backup/lib/storage"
 	"github.com/markkurossi/backup/lib/tree"
@@ -30,9 +32,57 @@ var ignoreSuffixes = []string{
 	"~",
 }
 
+// DefaultChunkSize defines the default file chunk size. Files smaller
+// than the chunk size are stored as simple files.
+const DefaultChunkSize = 1024 * 1024
+
+// Traverser traverses local directory trees and stores them into
+// the storage writer.
+type Traverser struct {
+	Writer    storage.Writer
+	ChunkSize int64
+	Excludes  []string
+	Files     int64
+	Dirs      int64
+	Size      int64
+}
+
+// NewTraverser creates a new traverser for the writer.
+func NewTraverser(writer storage.Writer) *Traverser {
+	return &Traverser{
+		Writer:    writer,
+		ChunkSize: DefaultChunkSize,
+	}
+}
+
 // Traverse traverses the directory tree root and stores it into
 // writer. The function returns the root element ID.
-func Traverse(root string, writer storage.Writer) (id storage.ID, err error) {
+func Traverse(root string, writer storage.Writer) (storage.ID, error) {
+	return NewTraverser(writer).Traverse(root)
+}
+
+func (t *Traverser) excluded(name string) bool {
+	_, ok := ignores[name]
+	if ok {
+		return true
+	}
+	for _, suffix := range ignoreSuffixes {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+	for _, pattern := range t.Excludes {
+		match, err := filepath.Match(pattern, name)
+		if err == nil && match {
+			return true
+		}
+	}
+	return false
+}
+
+// Traverse traverses the directory tree root and stores it into the
+// traverser's writer. The function returns the root element ID.
+func (t *Traverser) Traverse(root string) (id storage.ID, err error) {
 	fileInfo, err := os.Lstat(root)
 	if err != nil {
 		return
@@ -42,17 +92,10 @@ func Traverse(root string, writer storage.Writer) (id storage.ID, err error) {
 		return
 	}
 
-	// Check system ignores.
-	name := fileInfo.Name()
-	_, ok := ignores[name]
-	if ok {
+	// Check system ignores and excludes.
+	if t.excluded(fileInfo.Name()) {
 		return
 	}
-	for _, suffix := range ignoreSuffixes {
-		if strings.HasSuffix(name, suffix) {
-			return
-		}
-	}
 
 	// Directory.
 	if (mode & os.ModeDir) != 0 {
@@ -62,9 +105,10 @@ func Traverse(root string, writer storage.Writer) (id storage.ID, err error) {
 		}
 
 		dir := tree.NewDirectory()
+		t.Dirs++
 
 		for _, f := range files {
-			id, err = Traverse(fmt.Sprintf("%s/%s", root, f.Name()), writer)
+			id, err = t.Traverse(fmt.Sprintf("%s/%s", root, f.Name()))
 			if err != nil {
 				return id, err
 			}
@@ -86,24 +130,11 @@ func Traverse(root string, writer storage.Writer) (id storage.ID, err error) {
 		if err != nil {
 			return id, err
 		}
-		return writer.Write(data)
-	}
-
-	// Small files as simple files.
-	if fileInfo.Size() < 1024*1024 {
-		data, err := ioutil.ReadFile(root)
-		if err != nil {
-			return id, err
-		}
-		file := tree.NewSimpleFile(data)
-		data, err = file.Serialize()
-		if err != nil {
-			return id, err
-		}
-		return writer.Write(data)
+		return t.Writer.Write(data)
 	}
 
-	// Large files as compound files.
+	t.Files++
+	t.Size += fileInfo.Size()
 
 	file, err := os.Open(root)
 	if err != nil {
@@ -111,27 +142,42 @@ func Traverse(root string, writer storage.Writer) (id storage.ID, err error) {
 	}
 	defer file.Close()
 
-	buf := make([]byte, 1024*1024)
-	cf := tree.NewChunkedFile(fileInfo.Size())
+	id, _, err = tree.StoreFile(file, t.ChunkSize, t.Writer)
+	return id, err
+}
 
-	for {
-		read, err := file.Read(buf)
-		if read == 0 {
-			if err != io.EOF {
-				return id, err
-			}
-			break
-		}
-		id, err = writer.Write(buf[:read])
-		if err != nil {
-			return id, err
-		}
-		cf.Add(int64(read), id)
+// ValidFileName tests if name is a valid directory entry name. The
+// name must not be empty, "." or "..", or contain path separators.
+func ValidFileName(name string) bool {
+	return len(name) > 0 && name != "." && name != ".." &&
+		!strings.ContainsRune(name, '/')
+}
+
+// TraverseReader stores the content of the reader r as the file name
+// and wraps it into a directory with the file as its only entry. The
+// reader is read until EOF so it can be an unbounded stream. The name
+// must be a non-empty file name without path separators. The
+// function returns the directory element ID.
+func (t *Traverser) TraverseReader(name string, r io.Reader) (
+	storage.ID, error) {
+
+	if !ValidFileName(name) {
+		return storage.EmptyID, fmt.Errorf("invalid file name '%s'", name)
 	}
+	id, size, err := tree.StoreFile(r, t.ChunkSize, t.Writer)
+	if err != nil {
+		return id, err
+	}
+	t.Files++
+	t.Size += size
+
+	dir := tree.NewDirectory()
+	t.Dirs++
+	dir.Add(name, 0644, time.Now().Unix(), id)
 
-	data, err := cf.Serialize()
+	data, err := dir.Serialize()
 	if err != nil {
 		return id, err
 	}
-	return writer.Write(data)
+	return t.Writer.Write(data)
 }
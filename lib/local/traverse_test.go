@@ -0,0 +1,63 @@
+//
+// Copyright (c) 2026 Markku Rossi
+//
+// All rights reserved.
+//
+
+package local
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/markkurossi/backup/lib/storage"
+	"github.com/markkurossi/backup/lib/tree"
+)
+
+func TestTraverseReader(t *testing.T) {
+	st := storage.NewMemory()
+	traverser := NewTraverser(st)
+	traverser.ChunkSize = 16
+
+	content := []byte(strings.Repeat("Hello, world!\n", 10))
+	id, err := traverser.TraverseReader("hello.txt", bytes.NewReader(content))
+	if err != nil {
+		t.Fatalf("TraverseReader failed: %v", err)
+	}
+	el, err := tree.DeserializeID(id, st)
+	if err != nil {
+		t.Fatalf("DeserializeID failed: %v", err)
+	}
+	if !el.IsDir() {
+		t.Fatalf("unexpected element %T", el)
+	}
+	entries := el.Directory().Entries
+	if len(entries) != 1 || entries[0].Name != "hello.txt" {
+		t.Fatalf("unexpected entries: %v", entries)
+	}
+	el, err = tree.DeserializeID(entries[0].Entry, st)
+	if err != nil {
+		t.Fatalf("DeserializeID failed: %v", err)
+	}
+	data, err := io.ReadAll(el.File().Reader())
+	if err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+	if !bytes.Equal(data, content) {
+		t.Errorf("content mismatch")
+	}
+	if traverser.Files != 1 || traverser.Dirs != 1 ||
+		traverser.Size != int64(len(content)) {
+		t.Errorf("unexpected counts: files %d, dirs %d, size %d",
+			traverser.Files, traverser.Dirs, traverser.Size)
+	}
+
+	for _, name := range []string{"", ".", "..", "a/b", "/etc"} {
+		_, err := traverser.TraverseReader(name, bytes.NewReader(content))
+		if err == nil {
+			t.Errorf("TraverseReader accepted name '%s'", name)
+		}
+	}
+}
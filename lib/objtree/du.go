@@ -0,0 +1,207 @@
+//
+// Copyright (c) 2026 Markku Rossi
+//
+// All rights reserved.
+//
+
+package objtree
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/markkurossi/backup/lib/storage"
+	"github.com/markkurossi/backup/lib/tree"
+)
+
+// Referenced returns the set of object IDs that are referenced from
+// the snapshots. The set keys are the object ID data strings.
+func Referenced(snapshots []SnapshotRef, st storage.Accessor) (
+	map[string]bool, error) {
+
+	result := make(map[string]bool)
+
+	w := NewWalker(st)
+	w.Memoize = true
+	for _, ref := range snapshots {
+		err := w.Walk(ref.ID, func(n *Node) error {
+			result[string(n.ID.Data)] = true
+			if file, ok := n.Element.(*tree.ChunkedFile); ok && !n.Seen {
+				for _, chunk := range file.Chunks {
+					result[string(chunk.Content.Data)] = true
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// Usage describes the disk usage of a directory tree. The stored
+// sizes count each object once, in the first subtree where the
+// object is found.
+type Usage struct {
+	Name string
+	Path string
+	Dir  bool
+	// Size is the logical size of the tree.
+	Size tree.FileSize
+	// Unique is the stored size of the tree objects that are not in
+	// the shared set.
+	Unique tree.FileSize
+	// Shared is the stored size of the tree objects that are in the
+	// shared set.
+	Shared   tree.FileSize
+	Children []*Usage
+}
+
+// UsageOptions define the disk usage computation options.
+type UsageOptions struct {
+	// MaxDepth limits the depth of the returned usage tree. The value
+	// 0 does not limit the depth.
+	MaxDepth int
+	// Files includes files in the usage tree.
+	Files bool
+	// SortBySize sorts the children by their size in decreasing
+	// order. By default, the children are sorted by their name.
+	SortBySize bool
+	// Sizer returns the stored object sizes. If Sizer is nil, the
+	// stored sizes are not computed.
+	Sizer Sizer
+	// Shared is the set of shared object IDs, see Referenced.
+	Shared map[string]bool
+}
+
+type usageCollector struct {
+	opts *UsageOptions
+	// seen holds the objects that are already counted.
+	seen map[string]bool
+}
+
+// usageNode is a usage tree node whose subtree is being walked.
+type usageNode struct {
+	usage *Usage
+	depth int
+}
+
+// DiskUsage computes the disk usage of the tree root. The name is
+// used as the path of the root node.
+func DiskUsage(root storage.ID, name string, st storage.Accessor,
+	opts *UsageOptions) (*Usage, error) {
+
+	c := &usageCollector{
+		opts: opts,
+		seen: make(map[string]bool),
+	}
+	var result *Usage
+	var stack []usageNode
+
+	// done completes the walked nodes that are at the depth or
+	// deeper and adds their usage to their parents. The walker
+	// visits the nodes in pre-order so those subtrees are complete.
+	done := func(depth int) {
+		for len(stack) > 0 && stack[len(stack)-1].depth >= depth {
+			n := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			c.sort(n.usage.Children)
+			if len(stack) == 0 {
+				result = n.usage
+				continue
+			}
+			parent := stack[len(stack)-1]
+			parent.usage.Size += n.usage.Size
+			parent.usage.Unique += n.usage.Unique
+			parent.usage.Shared += n.usage.Shared
+			if opts.MaxDepth > 0 && parent.depth >= opts.MaxDepth {
+				continue
+			}
+			if n.usage.Dir || opts.Files {
+				parent.usage.Children = append(parent.usage.Children,
+					n.usage)
+			}
+		}
+	}
+
+	err := NewWalker(st).Walk(root, func(n *Node) error {
+		done(n.Depth)
+
+		u := &Usage{
+			Name: name,
+			Path: name,
+			Dir:  n.Element.IsDir(),
+		}
+		if n.Entry != nil {
+			u.Name = n.Entry.Name
+			u.Path = name + "/" + n.Path
+		}
+		if err := c.object(u, n.ID); err != nil {
+			return err
+		}
+
+		switch el := n.Element.(type) {
+		case *tree.Directory:
+			// The children are added to the directory when their
+			// subtrees are complete.
+
+		case *tree.ChunkedFile:
+			for _, chunk := range el.Chunks {
+				if err := c.object(u, chunk.Content); err != nil {
+					return err
+				}
+			}
+			u.Size = tree.FileSize(el.Size())
+
+		case *tree.SimpleFile:
+			u.Size = tree.FileSize(el.Size())
+
+		default:
+			return fmt.Errorf("unexpected element %T", n.Element)
+		}
+		stack = append(stack, usageNode{
+			usage: u,
+			depth: n.Depth,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	done(0)
+
+	return result, nil
+}
+
+// object adds the stored size of the object id to the usage u if
+// the object is not counted yet.
+func (c *usageCollector) object(u *Usage, id storage.ID) error {
+	if c.opts.Sizer == nil {
+		return nil
+	}
+	key := string(id.Data)
+	if c.seen[key] {
+		return nil
+	}
+	size, err := c.opts.Sizer(id)
+	if err != nil {
+		return err
+	}
+	c.seen[key] = true
+	if c.opts.Shared[key] {
+		u.Shared += tree.FileSize(size)
+	} else {
+		u.Unique += tree.FileSize(size)
+	}
+	return nil
+}
+
+func (c *usageCollector) sort(children []*Usage) {
+	sort.Slice(children, func(i, j int) bool {
+		if c.opts.SortBySize && children[i].Size != children[j].Size {
+			return children[i].Size > children[j].Size
+		}
+		return children[i].Name < children[j].Name
+	})
+}
@@ -0,0 +1,134 @@
+//
+// Copyright (c) 2026 Markku Rossi
+//
+// All rights reserved.
+//
+
+package objtree
+
+import (
+	"testing"
+
+	"github.com/markkurossi/backup/lib/storage"
+	"github.com/markkurossi/backup/lib/tree"
+)
+
+func TestDiskUsage(t *testing.T) {
+	st := storage.NewMemory()
+	head := makeTree(t, st)
+
+	snapshots, err := Snapshots(head, st)
+	if err != nil {
+		t.Fatalf("Snapshots failed: %v", err)
+	}
+	oldest := snapshots[1]
+
+	n, err := Lookup(oldest.ID, "", st)
+	if err != nil {
+		t.Fatalf("Lookup failed: %v", err)
+	}
+	root := n.ID
+	n, err = Lookup(oldest.ID, "/lib/b.go", st)
+	if err != nil {
+		t.Fatalf("Lookup failed: %v", err)
+	}
+	if n.Path != "lib/b.go" || n.Depth != 2 || n.Entry == nil || !n.Last {
+		t.Errorf("Lookup returned unexpected node %+v", n)
+	}
+	if _, err := Lookup(oldest.ID, "lib/c.go", st); err == nil {
+		t.Errorf("Lookup of a missing file succeeded")
+	}
+	if _, err := Lookup(oldest.ID, "README/x", st); err == nil {
+		t.Errorf("Lookup through a file succeeded")
+	}
+
+	shared, err := Referenced(snapshots[:1], st)
+	if err != nil {
+		t.Fatalf("Referenced failed: %v", err)
+	}
+	opts := &UsageOptions{
+		Files:      true,
+		SortBySize: true,
+		Sizer: func(id storage.ID) (int64, error) {
+			data, err := st.Read(id)
+			return int64(len(data)), err
+		},
+		Shared: shared,
+	}
+	u, err := DiskUsage(root, ".", st, opts)
+	if err != nil {
+		t.Fatalf("DiskUsage failed: %v", err)
+	}
+	if u.Size != 8 {
+		t.Errorf("size: got %d, expected 8", u.Size)
+	}
+	if len(u.Children) != 2 {
+		t.Fatalf("got %d children, expected 2", len(u.Children))
+	}
+	readme := u.Children[0]
+	lib := u.Children[1]
+	if readme.Path != "./README" || lib.Path != "./lib" {
+		t.Errorf("unexpected order: %s, %s", readme.Path, lib.Path)
+	}
+	if lib.Unique != 0 || lib.Shared == 0 {
+		t.Errorf("lib: unique %d, shared %d", lib.Unique, lib.Shared)
+	}
+	if readme.Unique == 0 || readme.Shared != 0 {
+		t.Errorf("README: unique %d, shared %d", readme.Unique, readme.Shared)
+	}
+	if u.Unique+u.Shared != readme.Unique+lib.Shared+sizeOf(t, st, root) {
+		t.Errorf("root: unique %d, shared %d", u.Unique, u.Shared)
+	}
+
+	opts.MaxDepth = 1
+	u, err = DiskUsage(root, ".", st, opts)
+	if err != nil {
+		t.Fatalf("DiskUsage failed: %v", err)
+	}
+	for _, child := range u.Children {
+		if len(child.Children) != 0 {
+			t.Errorf("%s: children beyond max depth", child.Path)
+		}
+	}
+}
+
+func TestDiskUsageDuplicates(t *testing.T) {
+	st := storage.NewMemory()
+
+	file := write(t, st, tree.NewSimpleFile([]byte("data")))
+	dir := tree.NewDirectory()
+	dir.Add("a", 0644, 0, file)
+	dir.Add("b", 0644, 0, file)
+	root := write(t, st, dir)
+
+	u, err := DiskUsage(root, ".", st, &UsageOptions{
+		Files: true,
+		Sizer: func(id storage.ID) (int64, error) {
+			data, err := st.Read(id)
+			return int64(len(data)), err
+		},
+	})
+	if err != nil {
+		t.Fatalf("DiskUsage failed: %v", err)
+	}
+	if u.Size != 8 {
+		t.Errorf("size: got %d, expected 8", u.Size)
+	}
+	expected := sizeOf(t, st, root) + sizeOf(t, st, file)
+	if u.Unique != expected || u.Shared != 0 {
+		t.Errorf("root: unique %d, shared %d, expected unique %d",
+			u.Unique, u.Shared, expected)
+	}
+	if len(u.Children) != 2 || u.Children[0].Unique == 0 ||
+		u.Children[1].Unique != 0 {
+		t.Errorf("duplicate object counted twice")
+	}
+}
+
+func sizeOf(t *testing.T, st storage.Accessor, id storage.ID) tree.FileSize {
+	data, err := st.Read(id)
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	return tree.FileSize(len(data))
+}
@@ -0,0 +1,64 @@
+//
+// Copyright (c) 2026 Markku Rossi
+//
+// All rights reserved.
+//
+
+package objtree
+
+// SnapshotFilter selects snapshots based on their metadata. The
+// empty filter fields match all snapshots. Version 1 snapshots do not
+// have metadata so they match only the empty filter.
+type SnapshotFilter struct {
+	Hostname string
+	Username string
+	Path     string
+	Tags     []string
+}
+
+// Metadata tests if the filter matches the snapshot metadata that
+// is not in the snapshot index.
+func (f *SnapshotFilter) Metadata() bool {
+	return f != nil &&
+		(len(f.Hostname) > 0 || len(f.Username) > 0 || len(f.Path) > 0)
+}
+
+// Match tests if the snapshot matches the filter. If the filter
+// matches the metadata that is not in the snapshot index, the
+// snapshot must be loaded.
+func (f *SnapshotFilter) Match(ref SnapshotRef) bool {
+	if f == nil {
+		return true
+	}
+	for _, tag := range f.Tags {
+		if !ref.HasTag(tag) {
+			return false
+		}
+	}
+	if !f.Metadata() {
+		return true
+	}
+	s := ref.Snapshot
+	if s == nil {
+		return false
+	}
+	if len(f.Hostname) > 0 && f.Hostname != s.Meta.Hostname {
+		return false
+	}
+	if len(f.Username) > 0 && f.Username != s.Meta.Username {
+		return false
+	}
+	if len(f.Path) > 0 {
+		var found bool
+		for _, p := range s.Meta.Paths {
+			if p == f.Path {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,216 @@
+//
+// Copyright (c) 2026 Markku Rossi
+//
+// All rights reserved.
+//
+
+package objtree
+
+import (
+	"path"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/markkurossi/backup/lib/storage"
+	"github.com/markkurossi/backup/lib/tree"
+)
+
+// FindQuery defines the file search criteria. The zero-valued
+// fields do not restrict the search.
+type FindQuery struct {
+	// Name is a glob pattern that the file name must match.
+	Name string
+	// Regexp is a regular expression that the file name must match.
+	Regexp *regexp.Regexp
+	// MinSize is the minimum file size.
+	MinSize int64
+	// MaxSize is the maximum file size.
+	MaxSize int64
+	// Newer selects files modified after the time.
+	Newer time.Time
+	// Older selects files modified before the time.
+	Older time.Time
+}
+
+func (q *FindQuery) match(e *tree.DirectoryEntry, size int64) bool {
+	if len(q.Name) > 0 {
+		ok, err := path.Match(q.Name, e.Name)
+		if err != nil || !ok {
+			return false
+		}
+	}
+	if q.Regexp != nil && !q.Regexp.MatchString(e.Name) {
+		return false
+	}
+	if q.MinSize > 0 && size < q.MinSize {
+		return false
+	}
+	if q.MaxSize > 0 && size > q.MaxSize {
+		return false
+	}
+	modTime := time.Unix(e.ModTime, 0)
+	if !q.Newer.IsZero() && !modTime.After(q.Newer) {
+		return false
+	}
+	if !q.Older.IsZero() && !modTime.Before(q.Older) {
+		return false
+	}
+	return true
+}
+
+// Match describes a found file version and the snapshots it appears
+// in.
+type Match struct {
+	Path      string
+	Entry     tree.DirectoryEntry
+	Size      int64
+	Snapshots []SnapshotRef
+}
+
+type relMatch struct {
+	path  string
+	entry tree.DirectoryEntry
+	size  int64
+}
+
+type finder struct {
+	st    storage.Accessor
+	query *FindQuery
+	// memo holds the matches of the already scanned directories,
+	// relative to the directory.
+	memo map[string][]relMatch
+}
+
+// Find searches the snapshots for the files that match the query.
+// The directories that are shared between snapshots are scanned
+// only once. The matches are sorted by their path and the
+// modification time.
+func Find(snapshots []SnapshotRef, st storage.Accessor, query *FindQuery) (
+	[]*Match, error) {
+
+	f := &finder{
+		st:    st,
+		query: query,
+		memo:  make(map[string][]relMatch),
+	}
+	byVersion := make(map[string]*Match)
+	var result []*Match
+
+	for _, ref := range snapshots {
+		if err := ref.Load(st); err != nil {
+			return nil, err
+		}
+		matches, err := f.scan(ref.Snapshot.Root)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range matches {
+			key := m.path + "\x00" + string(m.entry.Entry.Data)
+			match, ok := byVersion[key]
+			if !ok {
+				match = &Match{
+					Path:  m.path,
+					Entry: m.entry,
+					Size:  m.size,
+				}
+				byVersion[key] = match
+				result = append(result, match)
+			}
+			match.Snapshots = append(match.Snapshots, ref)
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].Path != result[j].Path {
+			return result[i].Path < result[j].Path
+		}
+		return result[i].Entry.ModTime < result[j].Entry.ModTime
+	})
+
+	return result, nil
+}
+
+// scanDir describes a directory whose matches are being collected.
+type scanDir struct {
+	key    string
+	depth  int
+	prefix string
+	start  int
+}
+
+// scan walks the directory tree root and returns the matching files.
+// The matches of each walked directory are memoized relative to the
+// directory so the directories that were already scanned are
+// skipped.
+func (f *finder) scan(root storage.ID) ([]relMatch, error) {
+	var matches []relMatch
+	var dirs []scanDir
+
+	// done memoizes the matches of the walked directories that are
+	// at the depth or deeper. The walker visits the nodes in
+	// pre-order so those directories are complete.
+	done := func(depth int) {
+		for len(dirs) > 0 && dirs[len(dirs)-1].depth >= depth {
+			d := dirs[len(dirs)-1]
+			dirs = dirs[:len(dirs)-1]
+			rel := []relMatch{}
+			for _, m := range matches[d.start:] {
+				rel = append(rel, relMatch{
+					path:  m.path[len(d.prefix):],
+					entry: m.entry,
+					size:  m.size,
+				})
+			}
+			f.memo[d.key] = rel
+		}
+	}
+
+	err := NewWalker(f.st).Walk(root, func(n *Node) error {
+		done(n.Depth)
+
+		var prefix string
+		if len(n.Path) > 0 {
+			prefix = n.Path + "/"
+		}
+		if n.Element.IsDir() {
+			key := string(n.ID.Data)
+			memo, ok := f.memo[key]
+			if ok {
+				for _, m := range memo {
+					matches = append(matches, relMatch{
+						path:  prefix + m.path,
+						entry: m.entry,
+						size:  m.size,
+					})
+				}
+				return SkipDir
+			}
+			dirs = append(dirs, scanDir{
+				key:    key,
+				depth:  n.Depth,
+				prefix: prefix,
+				start:  len(matches),
+			})
+			return nil
+		}
+		if n.Entry == nil {
+			return nil
+		}
+		size := n.Element.File().Size()
+		if f.query.match(n.Entry, size) {
+			matches = append(matches, relMatch{
+				path:  n.Path,
+				entry: *n.Entry,
+				size:  size,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	done(0)
+
+	return matches, nil
+}
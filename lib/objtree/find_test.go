@@ -0,0 +1,70 @@
+//
+// Copyright (c) 2026 Markku Rossi
+//
+// All rights reserved.
+//
+
+package objtree
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/markkurossi/backup/lib/storage"
+)
+
+func TestFind(t *testing.T) {
+	st := storage.NewMemory()
+	head := makeTree(t, st)
+
+	snapshots, err := Snapshots(head, st)
+	if err != nil {
+		t.Fatalf("Snapshots failed: %v", err)
+	}
+
+	for _, test := range []struct {
+		query FindQuery
+		paths []string
+		count []int
+	}{
+		{
+			query: FindQuery{Name: "*.go"},
+			paths: []string{"lib/a.go", "lib/b.go"},
+			count: []int{2, 2},
+		},
+		{
+			query: FindQuery{Regexp: regexp.MustCompile("^READ")},
+			paths: []string{"README"},
+			count: []int{1},
+		},
+		{
+			query: FindQuery{MinSize: 2},
+			paths: []string{"README"},
+			count: []int{1},
+		},
+		{
+			query: FindQuery{MaxSize: 1, Name: "b*"},
+			paths: []string{"lib/b.go"},
+			count: []int{2},
+		},
+	} {
+		matches, err := Find(snapshots, st, &test.query)
+		if err != nil {
+			t.Fatalf("Find failed: %v", err)
+		}
+		if len(matches) != len(test.paths) {
+			t.Fatalf("%v: got %d matches, expected %d", test.query,
+				len(matches), len(test.paths))
+		}
+		for idx, m := range matches {
+			if m.Path != test.paths[idx] {
+				t.Errorf("match %d: got %s, expected %s",
+					idx, m.Path, test.paths[idx])
+			}
+			if len(m.Snapshots) != test.count[idx] {
+				t.Errorf("%s: found in %d snapshots, expected %d",
+					m.Path, len(m.Snapshots), test.count[idx])
+			}
+		}
+	}
+}
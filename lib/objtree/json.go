@@ -0,0 +1,126 @@
+//
+// Copyright (c) 2026 Markku Rossi
+//
+// All rights reserved.
+//
+
+package objtree
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// SnapshotInfo describes a snapshot for machine-readable output.
+type SnapshotInfo struct {
+	ID              string             `json:"id"`
+	Created         time.Time          `json:"created"`
+	Parent          string             `json:"parent,omitempty"`
+	Root            string             `json:"root"`
+	Size            int64              `json:"size"`
+	Version         int                `json:"version"`
+	Hostname        string             `json:"hostname,omitempty"`
+	Username        string             `json:"username,omitempty"`
+	Paths           []string           `json:"paths,omitempty"`
+	ToolVersion     string             `json:"tool_version,omitempty"`
+	Tags            []string           `json:"tags,omitempty"`
+	Message         string             `json:"message,omitempty"`
+	Stats           *SnapshotStatsInfo `json:"stats,omitempty"`
+	Signer          string             `json:"signer,omitempty"`
+	SignatureStatus string             `json:"signature_status,omitempty"`
+}
+
+// SnapshotStatsInfo describes snapshot statistics for
+// machine-readable output.
+type SnapshotStatsInfo struct {
+	Files           int64 `json:"files"`
+	Dirs            int64 `json:"dirs"`
+	LogicalSize     int64 `json:"logical_size"`
+	NewBytes        int64 `json:"new_bytes"`
+	NewObjects      int64 `json:"new_objects"`
+	DedupBytes      int64 `json:"dedup_bytes"`
+	CompressedBytes int64 `json:"compressed_bytes"`
+	ElapsedNS       int64 `json:"elapsed_ns"`
+}
+
+// RepositoryStatsInfo describes repository statistics for
+// machine-readable output.
+type RepositoryStatsInfo struct {
+	Snapshots   int     `json:"snapshots"`
+	LogicalSize int64   `json:"logical_size"`
+	Objects     int64   `json:"objects"`
+	StoredSize  int64   `json:"stored_size"`
+	DedupRatio  float64 `json:"dedup_ratio"`
+}
+
+// NewSnapshotInfo creates the snapshot information for the snapshot
+// reference.
+func NewSnapshotInfo(ref SnapshotRef) *SnapshotInfo {
+	s := ref.Snapshot
+	info := &SnapshotInfo{
+		ID:      ref.ID.ToFullString(),
+		Created: time.Unix(0, s.Timestamp),
+		Root:    s.Root.ToFullString(),
+		Size:    int64(s.Size),
+		Version: int(s.Version),
+	}
+	if !s.Parent.Undefined() {
+		info.Parent = s.Parent.ToFullString()
+	}
+	if s.Version >= 2 {
+		info.Hostname = s.Meta.Hostname
+		info.Username = s.Meta.Username
+		info.Paths = s.Meta.Paths
+		info.ToolVersion = s.Meta.ToolVersion
+		info.Tags = s.Meta.Tags
+		info.Message = s.Meta.Message
+	}
+	if s.Version >= 3 {
+		info.Stats = &SnapshotStatsInfo{
+			Files:           s.Stats.Files,
+			Dirs:            s.Stats.Dirs,
+			LogicalSize:     int64(s.Stats.LogicalSize),
+			NewBytes:        int64(s.Stats.NewBytes),
+			NewObjects:      s.Stats.NewObjects,
+			DedupBytes:      int64(s.Stats.DedupBytes),
+			CompressedBytes: int64(s.Stats.CompressedBytes),
+			ElapsedNS:       s.Stats.Elapsed,
+		}
+	}
+	if s.Version >= 4 {
+		info.Signer = s.Signature.Signer
+	}
+	info.SignatureStatus = ref.Status
+	return info
+}
+
+// NewRepositoryStatsInfo creates the repository statistics
+// information.
+func NewRepositoryStatsInfo(stats *RepositoryStats) *RepositoryStatsInfo {
+	return &RepositoryStatsInfo{
+		Snapshots:   stats.Snapshots,
+		LogicalSize: int64(stats.LogicalSize),
+		Objects:     stats.Objects,
+		StoredSize:  int64(stats.StoredSize),
+		DedupRatio:  stats.DedupRatio(),
+	}
+}
+
+// ListSnapshotsJSON prints the snapshots that match the filter to
+// the writer as newline-delimited JSON objects. The snapshots must be
+// loaded.
+func ListSnapshotsJSON(w io.Writer, snapshots []SnapshotRef,
+	filter *SnapshotFilter) error {
+
+	enc := json.NewEncoder(w)
+	for _, ref := range snapshots {
+		if !filter.Match(ref) {
+			continue
+		}
+		if err := enc.Encode(NewSnapshotInfo(ref)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -7,17 +7,131 @@
 package objtree
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
+	"os"
+	"strings"
 	"time"
 
 	"github.com/markkurossi/backup/lib/storage"
 	"github.com/markkurossi/backup/lib/tree"
 )
 
+// Entry describes a directory tree entry.
+type Entry struct {
+	Path    string    `json:"path"`
+	Type    string    `json:"type"`
+	Mode    string    `json:"mode"`
+	ModTime time.Time `json:"mtime"`
+	Size    int64     `json:"size"`
+	ID      string    `json:"id"`
+
+	Name  string      `json:"-"`
+	Depth int         `json:"-"`
+	Last  bool        `json:"-"`
+	Entry storage.ID  `json:"-"`
+	Perm  os.FileMode `json:"-"`
+}
+
+// Entry types.
+const (
+	EntryDir  = "dir"
+	EntryFile = "file"
+)
+
+// NewEntry creates an entry for the directory entry node. The
+// function returns nil if the node does not have a directory entry.
+func NewEntry(n *Node) *Entry {
+	if n.Entry == nil {
+		return nil
+	}
+	entry := &Entry{
+		Path:    n.Path,
+		Mode:    n.Entry.Mode.String(),
+		ModTime: time.Unix(n.Entry.ModTime, 0),
+		ID:      n.ID.ToFullString(),
+		Name:    n.Entry.Name,
+		Depth:   n.Depth - 1,
+		Last:    n.Last,
+		Entry:   n.ID,
+		Perm:    n.Entry.Mode,
+	}
+	if n.Element.IsDir() {
+		entry.Type = EntryDir
+	} else {
+		entry.Type = EntryFile
+		entry.Size = n.Element.File().Size()
+	}
+	return entry
+}
+
+// Walk walks the directory tree root and calls fn for each entry in
+// depth-first pre-order. If root is a snapshot, the function walks
+// the snapshot's root directory.
+func Walk(root storage.ID, st storage.Accessor, fn func(e *Entry) error) error {
+	return NewWalker(st).Walk(root, func(n *Node) error {
+		entry := NewEntry(n)
+		if entry == nil {
+			return nil
+		}
+		return fn(entry)
+	})
+}
+
 // List prints the storage to standard output.
 func List(root storage.ID, st storage.Accessor, long bool) error {
 	now := time.Now()
-	return list(now, "", long, root, st)
+	var indent string
+	var lasts []bool
+
+	return NewWalker(st).Walk(root, func(n *Node) error {
+		snapshot := n.Snapshot()
+		if snapshot != nil {
+			printSnapshot(snapshot, "", long)
+			indent = "    "
+			return nil
+		}
+		e := NewEntry(n)
+		if e == nil {
+			return nil
+		}
+		lasts = append(lasts[:e.Depth], e.Last)
+
+		in := indent
+		for _, isLast := range lasts[:e.Depth] {
+			in = nest(in, isLast)
+		}
+		if e.Last {
+			in += "`-- "
+		} else {
+			in += "|-- "
+		}
+		fmt.Printf("%s%s", in, e.Name)
+		if long {
+			for i := 0; i+len(in)+len(e.Name) < 40; i++ {
+				fmt.Printf(" ")
+			}
+			var modStr string
+			if e.ModTime.Year() != now.Year() {
+				modStr = e.ModTime.Format("Jan _2  2006")
+			} else {
+				modStr = e.ModTime.Format("Jan _2 15:04")
+			}
+			fmt.Printf("\t%s\t%s\t%s", e.Perm, modStr, e.Entry)
+		}
+		fmt.Println()
+		return nil
+	})
+}
+
+// ListJSON prints the storage entries to the writer as
+// newline-delimited JSON objects.
+func ListJSON(w io.Writer, root storage.ID, st storage.Accessor) error {
+	enc := json.NewEncoder(w)
+	return Walk(root, st, func(e *Entry) error {
+		return enc.Encode(e)
+	})
 }
 
 func nest(indent string, isLast bool) string {
@@ -27,76 +141,70 @@ func nest(indent string, isLast bool) string {
 	return indent + "|   "
 }
 
-func list(now time.Time, indent string, long bool, root storage.ID,
-	st storage.Accessor) error {
-	element, err := tree.DeserializeID(root, st)
-	if err != nil {
-		return fmt.Errorf("failed to deserialize ID %s: %s", root, err)
+func printSnapshot(el *tree.Snapshot, status string, long bool) {
+	fmt.Printf("%s\n", el)
+	fmt.Printf("|-- Created: %s\n", time.Unix(0, el.Timestamp))
+	if long && el.Version >= 2 {
+		fmt.Printf("|-- Host   : %s\n", el.Meta.Hostname)
+		fmt.Printf("|-- User   : %s\n", el.Meta.Username)
+		for _, p := range el.Meta.Paths {
+			fmt.Printf("|-- Path   : %s\n", p)
+		}
+		fmt.Printf("|-- Tool   : %s\n", el.Meta.ToolVersion)
 	}
-
-	switch el := element.(type) {
-	case *tree.Snapshot:
-		fmt.Printf("%s\n", el)
-		fmt.Printf("|-- Created: %s\n", time.Unix(0, el.Timestamp))
-		fmt.Printf("|-- Parent : %s\n", el.Parent)
-		fmt.Printf("`-- Root   : %s\n", el.Root)
-		return list(now, indent+"    ", long, el.Root, st)
-
-	case *tree.Directory:
-		count := len(el.Entries)
-		for idx, e := range el.Entries {
-			var in string
-			var isLast bool
-			if idx+1 == count {
-				in = indent + "`-- "
-				isLast = true
-			} else {
-				in = indent + "|-- "
-			}
-			fmt.Printf("%s%s", in, e.Name)
-			if long {
-				for i := 0; i+len(in)+len(e.Name) < 40; i++ {
-					fmt.Printf(" ")
-				}
-				modified := time.Unix(e.ModTime, 0)
-				var modStr string
-				if modified.Year() != now.Year() {
-					modStr = modified.Format("Jan _2  2006")
-				} else {
-					modStr = modified.Format("Jan _2 15:04")
-				}
-				fmt.Printf("\t%s\t%s\t%s", e.Mode, modStr, e.Entry)
-			}
-			fmt.Println()
-			err := list(now, nest(indent, isLast), long, e.Entry, st)
-			if err != nil {
-				return err
-			}
+	if el.Version >= 2 {
+		if len(el.Meta.Tags) > 0 {
+			fmt.Printf("|-- Tags   : %s\n", strings.Join(el.Meta.Tags, ", "))
+		}
+		if len(el.Meta.Message) > 0 {
+			fmt.Printf("|-- Message: %s\n", el.Meta.Message)
 		}
 	}
-
-	return nil
+	if len(status) > 0 {
+		fmt.Printf("|-- Signed : %s\n", status)
+	} else if long && el.Version >= 4 && len(el.Signature.Signer) > 0 {
+		fmt.Printf("|-- Signer : %s\n", el.Signature.Signer)
+	}
+	fmt.Printf("|-- Parent : %s\n", el.Parent)
+	fmt.Printf("`-- Root   : %s\n", el.Root)
 }
 
-// ListSnapshots lists snapshots to standard output.
-func ListSnapshots(root storage.ID, st storage.Accessor, long bool) error {
-	for !root.Undefined() {
-		element, err := tree.DeserializeID(root, st)
-		if err != nil {
-			return fmt.Errorf("failed to deserialize ID '%s': %s", root, err)
-		}
+// ListSnapshots lists the snapshots that match the filter to
+// standard output. The short format lists the snapshot IDs, creation
+// times, and tags, and the long format the snapshot objects, which
+// must be loaded.
+func ListSnapshots(snapshots []SnapshotRef, long bool,
+	filter *SnapshotFilter) {
 
-		switch el := element.(type) {
-		case *tree.Snapshot:
-			fmt.Printf("%s\n", el)
-			fmt.Printf("|-- Created: %s\n", time.Unix(0, el.Timestamp))
-			fmt.Printf("|-- Parent : %s\n", el.Parent)
-			fmt.Printf("`-- Root   : %s\n", el.Root)
-			root = el.Parent
+	for _, ref := range snapshots {
+		if !filter.Match(ref) {
+			continue
+		}
+		if long {
+			printSnapshot(ref.Snapshot, ref.Status, long)
+		} else {
+			printSnapshotRef(ref)
+		}
+	}
+}
 
-		default:
-			return fmt.Errorf("ID %s is not a snapshot", root)
+func printSnapshotRef(ref SnapshotRef) {
+	fmt.Printf("Snapshot %s\n", ref.ID)
+	lines := []string{
+		fmt.Sprintf("Created: %s", ref.Time()),
+	}
+	if len(ref.Tags) > 0 {
+		lines = append(lines,
+			fmt.Sprintf("Tags   : %s", strings.Join(ref.Tags, ", ")))
+	}
+	if len(ref.Status) > 0 {
+		lines = append(lines, fmt.Sprintf("Signed : %s", ref.Status))
+	}
+	for idx, line := range lines {
+		if idx+1 < len(lines) {
+			fmt.Printf("|-- %s\n", line)
+		} else {
+			fmt.Printf("`-- %s\n", line)
 		}
 	}
-	return nil
 }
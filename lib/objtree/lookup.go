@@ -0,0 +1,80 @@
+//
+// Copyright (c) 2026 Markku Rossi
+//
+// All rights reserved.
+//
+
+package objtree
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/markkurossi/backup/lib/storage"
+	"github.com/markkurossi/backup/lib/tree"
+)
+
+// Lookup finds the node at the slash-separated path from the
+// root. The root can be a snapshot or a directory. The returned node
+// has the directory entry of the element unless the path specifies
+// the root directory.
+func Lookup(root storage.ID, path string, st storage.Accessor) (
+	*Node, error) {
+
+	element, err := tree.DeserializeID(root, st)
+	if err != nil {
+		return nil, fmt.Errorf("failed to deserialize ID %s: %s", root, err)
+	}
+	if snapshot, ok := element.(*tree.Snapshot); ok {
+		root = snapshot.Root
+		element, err = tree.DeserializeID(root, st)
+		if err != nil {
+			return nil, fmt.Errorf("failed to deserialize ID %s: %s",
+				root, err)
+		}
+	}
+	n := &Node{
+		ID:      root,
+		Element: element,
+		Last:    true,
+	}
+
+	for _, name := range strings.Split(path, "/") {
+		if len(name) == 0 || name == "." {
+			continue
+		}
+		dir, ok := n.Element.(*tree.Directory)
+		if !ok {
+			return nil, fmt.Errorf("%s: not a directory", n.Path)
+		}
+		var entry *tree.DirectoryEntry
+		var last bool
+		for idx := range dir.Entries {
+			if dir.Entries[idx].Name == name {
+				entry = &dir.Entries[idx]
+				last = idx+1 == len(dir.Entries)
+				break
+			}
+		}
+		if len(n.Path) > 0 {
+			name = n.Path + "/" + name
+		}
+		if entry == nil {
+			return nil, fmt.Errorf("%s: not found", name)
+		}
+		element, err = tree.DeserializeID(entry.Entry, st)
+		if err != nil {
+			return nil, fmt.Errorf("failed to deserialize ID %s: %s",
+				entry.Entry, err)
+		}
+		n = &Node{
+			Path:    name,
+			Depth:   n.Depth + 1,
+			ID:      entry.Entry,
+			Element: element,
+			Entry:   entry,
+			Last:    last,
+		}
+	}
+	return n, nil
+}
@@ -0,0 +1,251 @@
+//
+// Copyright (c) 2026 Markku Rossi
+//
+// All rights reserved.
+//
+
+package objtree
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/markkurossi/backup/lib/storage"
+	"github.com/markkurossi/backup/lib/tree"
+)
+
+// SnapshotRef references a snapshot object. The references that
+// are listed from the snapshot index have only the snapshot ID,
+// timestamp, and tags, and their Snapshot is nil until it is loaded
+// with Load.
+type SnapshotRef struct {
+	ID        storage.ID
+	Timestamp int64
+	Tags      []string
+	Snapshot  *tree.Snapshot
+	// Status is the snapshot signature verification status. It is
+	// empty if the signature was not verified.
+	Status string
+}
+
+func newSnapshotRef(id storage.ID, s *tree.Snapshot) SnapshotRef {
+	ref := SnapshotRef{
+		ID:        id,
+		Timestamp: s.Timestamp,
+		Snapshot:  s,
+	}
+	if s.Version >= 2 {
+		ref.Tags = s.Meta.Tags
+	}
+	return ref
+}
+
+// Time returns the snapshot creation time.
+func (ref SnapshotRef) Time() time.Time {
+	return time.Unix(0, ref.Timestamp)
+}
+
+// HasTag tests if the snapshot has the tag.
+func (ref SnapshotRef) HasTag(tag string) bool {
+	for _, t := range ref.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// Load loads the snapshot object if it is not loaded yet.
+func (ref *SnapshotRef) Load(st storage.Accessor) error {
+	if ref.Snapshot != nil {
+		return nil
+	}
+	element, err := tree.DeserializeID(ref.ID, st)
+	if err != nil {
+		return fmt.Errorf("failed to deserialize ID '%s': %s", ref.ID, err)
+	}
+	snapshot, ok := element.(*tree.Snapshot)
+	if !ok {
+		return fmt.Errorf("ID %s is not a snapshot", ref.ID)
+	}
+	ref.Snapshot = snapshot
+	return nil
+}
+
+// Snapshots returns the snapshot chain starting from head. The
+// snapshots are returned from the newest to the oldest.
+func Snapshots(head storage.ID, st storage.Accessor) ([]SnapshotRef, error) {
+	var result []SnapshotRef
+
+	err := NewWalker(st).WalkSnapshots(head, func(n *Node) error {
+		result = append(result, newSnapshotRef(n.ID, n.Snapshot()))
+		return SkipDir
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// IndexSnapshots returns the snapshots of the snapshot index. The
+// snapshots are returned from the newest to the oldest. The snapshot
+// objects are not loaded.
+func IndexSnapshots(index *tree.SnapshotIndex) []SnapshotRef {
+	var result []SnapshotRef
+	for _, entry := range index.Snapshots {
+		result = append(result, SnapshotRef{
+			ID:        entry.ID,
+			Timestamp: entry.Timestamp,
+			Tags:      entry.Tags,
+		})
+	}
+	return result
+}
+
+// LoadSnapshots loads the snapshot objects of the snapshots. The
+// function returns the loaded snapshots and the errors of the
+// snapshots that could not be loaded.
+func LoadSnapshots(snapshots []SnapshotRef, st storage.Accessor) (
+	[]SnapshotRef, []error) {
+
+	var result []SnapshotRef
+	var errs []error
+	for _, ref := range snapshots {
+		if err := ref.Load(st); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		result = append(result, ref)
+	}
+	return result, errs
+}
+
+var dateLayouts = []struct {
+	layout string
+	span   time.Duration
+}{
+	{"2006-01-02", 24 * time.Hour},
+	{"2006-01-02T15:04", time.Minute},
+	{"2006-01-02 15:04", time.Minute},
+	{"2006-01-02T15:04:05", time.Second},
+	{"2006-01-02 15:04:05", time.Second},
+}
+
+// Select selects a snapshot from the snapshots that are ordered from
+// the newest to the oldest. The selector can be one of the
+// following:
+//
+//	latest           the newest snapshot
+//	latest~N         the Nth snapshot before the newest snapshot
+//	2026-05-01       the newest snapshot as of the date or time
+//	2026-05-01T10:00 (also RFC 3339 timestamps are accepted)
+//	1a2b3c           the snapshot with the unique ID prefix
+//	tag:name, name   the newest snapshot with the tag
+func Select(snapshots []SnapshotRef, selector string) (SnapshotRef, error) {
+	if len(snapshots) == 0 {
+		return SnapshotRef{}, fmt.Errorf("no snapshots")
+	}
+	if len(selector) == 0 || selector == "latest" {
+		return snapshots[0], nil
+	}
+	if strings.HasPrefix(selector, "latest~") {
+		n, err := strconv.Atoi(selector[7:])
+		if err != nil || n < 0 {
+			return SnapshotRef{}, fmt.Errorf("invalid selector '%s'", selector)
+		}
+		if n >= len(snapshots) {
+			return SnapshotRef{}, fmt.Errorf("%s: only %d snapshots", selector,
+				len(snapshots))
+		}
+		return snapshots[n], nil
+	}
+	if strings.HasPrefix(selector, "tag:") {
+		return selectTag(snapshots, selector[4:])
+	}
+
+	// Date and time.
+	if t, span, ok := parseTime(selector); ok {
+		return selectTime(snapshots, selector, t.Add(span))
+	}
+
+	// ID prefix.
+	if isHex(selector) {
+		var matches []SnapshotRef
+		for _, s := range snapshots {
+			if s.ID.HasPrefix(selector) {
+				matches = append(matches, s)
+			}
+		}
+		switch len(matches) {
+		case 0:
+		case 1:
+			return matches[0], nil
+		default:
+			return SnapshotRef{}, fmt.Errorf(
+				"ambiguous snapshot ID prefix '%s' matches %d snapshots",
+				selector, len(matches))
+		}
+	}
+
+	return selectTag(snapshots, selector)
+}
+
+// ParseTime parses the date or time value. The value can be an RFC
+// 3339 timestamp or a local date or time in one of the selector
+// formats.
+func ParseTime(value string) (time.Time, error) {
+	t, _, ok := parseTime(value)
+	if !ok {
+		return time.Time{}, fmt.Errorf("invalid time '%s'", value)
+	}
+	return t, nil
+}
+
+// parseTime parses the time value and returns the time and the span
+// of the value's precision.
+func parseTime(value string) (time.Time, time.Duration, bool) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, 0, true
+	}
+	for _, l := range dateLayouts {
+		t, err := time.ParseInLocation(l.layout, value, time.Local)
+		if err == nil {
+			return t, l.span, true
+		}
+	}
+	return time.Time{}, 0, false
+}
+
+// selectTime selects the newest snapshot that was created before
+// the time t.
+func selectTime(snapshots []SnapshotRef, selector string, t time.Time) (
+	SnapshotRef, error) {
+
+	for _, s := range snapshots {
+		if s.Time().Before(t) {
+			return s, nil
+		}
+	}
+	return SnapshotRef{}, fmt.Errorf("no snapshots as of %s", selector)
+}
+
+func selectTag(snapshots []SnapshotRef, tag string) (SnapshotRef, error) {
+	for _, s := range snapshots {
+		if s.HasTag(tag) {
+			return s, nil
+		}
+	}
+	return SnapshotRef{}, fmt.Errorf("snapshot '%s' not found", tag)
+}
+
+func isHex(s string) bool {
+	for _, r := range s {
+		if !(r >= '0' && r <= '9' || r >= 'a' && r <= 'f' ||
+			r >= 'A' && r <= 'F') {
+			return false
+		}
+	}
+	return len(s) > 0
+}
@@ -0,0 +1,113 @@
+//
+// Copyright (c) 2026 Markku Rossi
+//
+// All rights reserved.
+//
+
+package objtree
+
+import (
+	"testing"
+	"time"
+
+	"github.com/markkurossi/backup/lib/storage"
+	"github.com/markkurossi/backup/lib/tree"
+)
+
+func makeSnapshots() []SnapshotRef {
+	var result []SnapshotRef
+	for i, d := range []struct {
+		id   []byte
+		date string
+		tags []string
+	}{
+		{[]byte{0xab, 0xcd, 0x01}, "2026-05-03T12:00:00Z", nil},
+		{[]byte{0xab, 0xce, 0x02}, "2026-05-02T12:00:00Z", []string{"daily"}},
+		{[]byte{0x12, 0x34, 0x03}, "2026-04-30T12:00:00Z", []string{"daily"}},
+	} {
+		t, err := time.Parse(time.RFC3339, d.date)
+		if err != nil {
+			panic(err)
+		}
+		s := tree.NewSnapshot()
+		s.Timestamp = t.UnixNano()
+		s.Meta.Tags = d.tags
+		s.Size = tree.FileSize(i)
+		result = append(result, newSnapshotRef(storage.NewID(d.id), s))
+	}
+	return result
+}
+
+func TestSelect(t *testing.T) {
+	snapshots := makeSnapshots()
+
+	for _, test := range []struct {
+		selector string
+		index    int
+	}{
+		{"", 0},
+		{"latest", 0},
+		{"latest~2", 2},
+		{"abce", 1},
+		{"12", 2},
+		{"daily", 1},
+		{"tag:daily", 1},
+		{"2026-05-02T13:00:00Z", 1},
+		{"2026-05-01T00:00:00Z", 2},
+	} {
+		ref, err := Select(snapshots, test.selector)
+		if err != nil {
+			t.Errorf("Select(%q) failed: %v", test.selector, err)
+			continue
+		}
+		if !ref.ID.Equal(snapshots[test.index].ID) {
+			t.Errorf("Select(%q): got %s, expected %s", test.selector,
+				ref.ID, snapshots[test.index].ID)
+		}
+	}
+
+	for _, selector := range []string{
+		"latest~3", "latest~x", "ab", "weekly", "2026-04-01",
+	} {
+		_, err := Select(snapshots, selector)
+		if err == nil {
+			t.Errorf("Select(%q) succeeded", selector)
+		}
+	}
+}
+
+func TestIndexSnapshots(t *testing.T) {
+	st := storage.NewMemory()
+
+	index := tree.NewSnapshotIndex()
+	for i, tag := range []string{"daily", "weekly"} {
+		s := tree.NewSnapshot()
+		s.Timestamp = int64(i + 1)
+		s.Meta.Tags = []string{tag}
+		id := write(t, st, s)
+		if i == 0 {
+			// The daily snapshot is missing from the storage.
+			id = storage.NewID([]byte{0x01, 0x02, 0x03})
+		}
+		index.Add(id, s)
+	}
+
+	snapshots := IndexSnapshots(index)
+	if len(snapshots) != 2 {
+		t.Fatalf("IndexSnapshots: got %d snapshots, expected 2",
+			len(snapshots))
+	}
+	ref, err := Select(snapshots, "daily")
+	if err != nil || ref.Snapshot != nil || !ref.ID.Equal(snapshots[1].ID) {
+		t.Errorf("Select(daily): got %v, %v", ref.ID, err)
+	}
+
+	loaded, errs := LoadSnapshots(snapshots, st)
+	if len(loaded) != 1 || len(errs) != 1 {
+		t.Fatalf("LoadSnapshots: got %d snapshots and %d errors",
+			len(loaded), len(errs))
+	}
+	if !loaded[0].Snapshot.HasTag("weekly") {
+		t.Errorf("LoadSnapshots: got wrong snapshot %s", loaded[0].ID)
+	}
+}
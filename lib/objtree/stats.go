@@ -0,0 +1,137 @@
+//
+// Copyright (c) 2026 Markku Rossi
+//
+// All rights reserved.
+//
+
+package objtree
+
+import (
+	"fmt"
+
+	"github.com/markkurossi/backup/lib/storage"
+	"github.com/markkurossi/backup/lib/tree"
+)
+
+// Sizer returns the stored size of the object id.
+type Sizer func(id storage.ID) (int64, error)
+
+// RepositoryStats define statistics over all snapshots.
+type RepositoryStats struct {
+	Snapshots int
+	// LogicalSize is the sum of the logical sizes of all snapshots.
+	LogicalSize tree.FileSize
+	// Objects is the number of unique objects reachable from the
+	// snapshots.
+	Objects int64
+	// StoredSize is the stored size of the unique objects.
+	StoredSize tree.FileSize
+}
+
+// DedupRatio returns the ratio of the logical size and the stored
+// size.
+func (stats *RepositoryStats) DedupRatio() float64 {
+	if stats.StoredSize == 0 {
+		return 0
+	}
+	return float64(stats.LogicalSize) / float64(stats.StoredSize)
+}
+
+type statsCollector struct {
+	st       storage.Accessor
+	sizer    Sizer
+	stats    *RepositoryStats
+	seen     map[string]bool
+	dirSizes map[string]int64
+}
+
+// Stats computes repository statistics over the snapshots.
+func Stats(snapshots []SnapshotRef, st storage.Accessor, sizer Sizer) (
+	*RepositoryStats, error) {
+
+	c := &statsCollector{
+		st:       st,
+		sizer:    sizer,
+		stats:    new(RepositoryStats),
+		seen:     make(map[string]bool),
+		dirSizes: make(map[string]int64),
+	}
+
+	for _, ref := range snapshots {
+		if err := ref.Load(st); err != nil {
+			return nil, err
+		}
+		if err := c.object(ref.ID); err != nil {
+			return nil, err
+		}
+		size, err := c.size(ref.Snapshot.Root)
+		if err != nil {
+			return nil, err
+		}
+		c.stats.Snapshots++
+		c.stats.LogicalSize += tree.FileSize(size)
+	}
+	return c.stats, nil
+}
+
+// object counts the object id if it was not already seen.
+func (c *statsCollector) object(id storage.ID) error {
+	key := string(id.Data)
+	if c.seen[key] {
+		return nil
+	}
+	c.seen[key] = true
+
+	size, err := c.sizer(id)
+	if err != nil {
+		return err
+	}
+	c.stats.Objects++
+	c.stats.StoredSize += tree.FileSize(size)
+	return nil
+}
+
+// size returns the logical size of the element id and counts the
+// objects it references.
+func (c *statsCollector) size(id storage.ID) (int64, error) {
+	key := string(id.Data)
+	size, ok := c.dirSizes[key]
+	if ok {
+		return size, nil
+	}
+	if err := c.object(id); err != nil {
+		return 0, err
+	}
+	element, err := tree.DeserializeID(id, c.st)
+	if err != nil {
+		return 0, fmt.Errorf("failed to deserialize ID %s: %s", id, err)
+	}
+
+	switch el := element.(type) {
+	case *tree.Directory:
+		for _, e := range el.Entries {
+			s, err := c.size(e.Entry)
+			if err != nil {
+				return 0, err
+			}
+			size += s
+		}
+
+	case *tree.ChunkedFile:
+		for _, chunk := range el.Chunks {
+			if err := c.object(chunk.Content); err != nil {
+				return 0, err
+			}
+		}
+		size = el.Size()
+
+	case *tree.SimpleFile:
+		size = el.Size()
+
+	default:
+		return 0, fmt.Errorf("unexpected element %T", element)
+	}
+	c.dirSizes[key] = size
+
+	return size, nil
+}
@@ -0,0 +1,214 @@
+//
+// Copyright (c) 2026 Markku Rossi
+//
+// All rights reserved.
+//
+
+package objtree
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/markkurossi/backup/lib/storage"
+	"github.com/markkurossi/backup/lib/tree"
+)
+
+// SkipDir can be returned from a VisitFunc to skip the children of
+// the visited node. For snapshot nodes, it skips the snapshot tree.
+var SkipDir = errors.New("skip this directory")
+
+// Node describes a visited tree node.
+type Node struct {
+	// Path is the slash-separated path of the node from the snapshot
+	// root. The path of the snapshot and the root directory is "".
+	Path string
+	// Depth is the node depth. The snapshot and the root directory
+	// have depth 0 and the root directory entries have depth 1.
+	Depth int
+	// ID is the node's object ID.
+	ID storage.ID
+	// Element is the node's tree element.
+	Element tree.Element
+	// Entry is the directory entry of the node. It is nil for the
+	// snapshot and the root directory.
+	Entry *tree.DirectoryEntry
+	// Last tells if the node is the last entry of its directory.
+	Last bool
+	// Seen tells if the node was already visited with memoization
+	// enabled. The children of seen nodes are not visited again.
+	Seen bool
+}
+
+// Snapshot returns the node's snapshot element or nil if the node
+// is not a snapshot.
+func (n *Node) Snapshot() *tree.Snapshot {
+	s, _ := n.Element.(*tree.Snapshot)
+	return s
+}
+
+// VisitFunc is called for each visited node.
+type VisitFunc func(n *Node) error
+
+// Walker walks object trees.
+type Walker struct {
+	st storage.Accessor
+	// MaxDepth limits the depth of the visited nodes. The value 0
+	// does not limit the depth.
+	MaxDepth int
+	// Prefetch defines the number of parallel workers that fetch
+	// directory entries. The value 0 disables prefetching.
+	Prefetch int
+	// Memoize enables memoization of visited object IDs so that
+	// each subtree is walked only once.
+	Memoize bool
+	seen    map[string]bool
+}
+
+// NewWalker creates a new walker for the storage.
+func NewWalker(st storage.Accessor) *Walker {
+	return &Walker{
+		st:   st,
+		seen: make(map[string]bool),
+	}
+}
+
+// Walk walks the object tree root and calls fn for each node in
+// depth-first pre-order. The root can be a snapshot or a directory.
+func (w *Walker) Walk(root storage.ID, fn VisitFunc) error {
+	element, err := tree.DeserializeID(root, w.st)
+	if err != nil {
+		return fmt.Errorf("failed to deserialize ID %s: %s", root, err)
+	}
+	return w.visit(&Node{
+		ID:      root,
+		Element: element,
+		Last:    true,
+	}, fn)
+}
+
+// WalkSnapshots walks the snapshot chain that starts from head and
+// calls fn for each snapshot and the nodes of its tree. The function
+// fn can return SkipDir for the snapshot nodes to visit only the
+// snapshots.
+func (w *Walker) WalkSnapshots(head storage.ID, fn VisitFunc) error {
+	for id := head; !id.Undefined(); {
+		element, err := tree.DeserializeID(id, w.st)
+		if err != nil {
+			return fmt.Errorf("failed to deserialize ID '%s': %s", id, err)
+		}
+		snapshot, ok := element.(*tree.Snapshot)
+		if !ok {
+			return fmt.Errorf("ID %s is not a snapshot", id)
+		}
+		err = w.visit(&Node{
+			ID:      id,
+			Element: snapshot,
+			Last:    true,
+		}, fn)
+		if err != nil {
+			return err
+		}
+		id = snapshot.Parent
+	}
+	return nil
+}
+
+func (w *Walker) visit(n *Node, fn VisitFunc) error {
+	if w.Memoize {
+		key := string(n.ID.Data)
+		n.Seen = w.seen[key]
+		w.seen[key] = true
+	}
+	err := fn(n)
+	if err == SkipDir {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if n.Seen {
+		return nil
+	}
+
+	switch el := n.Element.(type) {
+	case *tree.Snapshot:
+		element, err := tree.DeserializeID(el.Root, w.st)
+		if err != nil {
+			return fmt.Errorf("failed to deserialize ID %s: %s", el.Root, err)
+		}
+		return w.visit(&Node{
+			ID:      el.Root,
+			Element: element,
+			Last:    true,
+		}, fn)
+
+	case *tree.Directory:
+		if w.MaxDepth > 0 && n.Depth >= w.MaxDepth {
+			return nil
+		}
+		children, err := w.fetch(el.Entries)
+		if err != nil {
+			return err
+		}
+		var prefix string
+		if len(n.Path) > 0 {
+			prefix = n.Path + "/"
+		}
+		for idx := range el.Entries {
+			e := &el.Entries[idx]
+			err := w.visit(&Node{
+				Path:    prefix + e.Name,
+				Depth:   n.Depth + 1,
+				ID:      e.Entry,
+				Element: children[idx],
+				Entry:   e,
+				Last:    idx+1 == len(el.Entries),
+			}, fn)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// fetch fetches the elements of the directory entries.
+func (w *Walker) fetch(entries []tree.DirectoryEntry) ([]tree.Element, error) {
+	result := make([]tree.Element, len(entries))
+	errs := make([]error, len(entries))
+
+	if w.Prefetch <= 0 || len(entries) < 2 {
+		for idx, e := range entries {
+			result[idx], errs[idx] = tree.DeserializeID(e.Entry, w.st)
+			if errs[idx] != nil {
+				return nil, fmt.Errorf("failed to deserialize ID %s: %s",
+					e.Entry, errs[idx])
+			}
+		}
+		return result, nil
+	}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, w.Prefetch)
+
+	for idx, e := range entries {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(idx int, id storage.ID) {
+			defer wg.Done()
+			result[idx], errs[idx] = tree.DeserializeID(id, w.st)
+			<-sem
+		}(idx, e.Entry)
+	}
+	wg.Wait()
+
+	for idx, err := range errs {
+		if err != nil {
+			return nil, fmt.Errorf("failed to deserialize ID %s: %s",
+				entries[idx].Entry, err)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,141 @@
+//
+// Copyright (c) 2026 Markku Rossi
+//
+// All rights reserved.
+//
+
+package objtree
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/markkurossi/backup/lib/storage"
+	"github.com/markkurossi/backup/lib/tree"
+)
+
+func write(t *testing.T, st storage.Writer, el tree.Element) storage.ID {
+	data, err := el.Serialize()
+	if err != nil {
+		t.Fatalf("Failed to serialize element: %v", err)
+	}
+	id, err := st.Write(data)
+	if err != nil {
+		t.Fatalf("Failed to write element: %v", err)
+	}
+	return id
+}
+
+// makeTree creates a snapshot chain with two snapshots that share
+// the directory "lib".
+func makeTree(t *testing.T, st storage.Accessor) storage.ID {
+	lib := tree.NewDirectory()
+	lib.Add("a.go", 0644, 0, write(t, st, tree.NewSimpleFile([]byte("a"))))
+	lib.Add("b.go", 0644, 0, write(t, st, tree.NewSimpleFile([]byte("b"))))
+	libID := write(t, st, lib)
+
+	root1 := tree.NewDirectory()
+	root1.Add("lib", os.ModeDir|0755, 0, libID)
+	root1.Add("README", 0644, 0,
+		write(t, st, tree.NewSimpleFile([]byte("readme"))))
+
+	s1 := tree.NewSnapshot()
+	s1.Root = write(t, st, root1)
+	s1ID := write(t, st, s1)
+
+	root2 := tree.NewDirectory()
+	root2.Add("lib", os.ModeDir|0755, 0, libID)
+
+	s2 := tree.NewSnapshot()
+	s2.Root = write(t, st, root2)
+	s2.Parent = s1ID
+
+	return write(t, st, s2)
+}
+
+func TestWalker(t *testing.T) {
+	st := storage.NewMemory()
+	head := makeTree(t, st)
+
+	for _, test := range []struct {
+		memoize  bool
+		prefetch int
+		maxDepth int
+		skip     string
+		paths    []string
+	}{
+		{
+			paths: []string{
+				"/", "", "lib", "lib/a.go", "lib/b.go",
+				"/", "", "lib", "lib/a.go", "lib/b.go", "README",
+			},
+		},
+		{
+			memoize: true,
+			paths: []string{
+				"/", "", "lib", "lib/a.go", "lib/b.go",
+				"/", "", "lib", "README",
+			},
+		},
+		{
+			prefetch: 4,
+			maxDepth: 1,
+			paths: []string{
+				"/", "", "lib",
+				"/", "", "lib", "README",
+			},
+		},
+		{
+			skip: "lib",
+			paths: []string{
+				"/", "", "lib",
+				"/", "", "lib", "README",
+			},
+		},
+	} {
+		w := NewWalker(st)
+		w.Memoize = test.memoize
+		w.Prefetch = test.prefetch
+		w.MaxDepth = test.maxDepth
+
+		var paths []string
+		err := w.WalkSnapshots(head, func(n *Node) error {
+			if n.Snapshot() != nil {
+				paths = append(paths, "/")
+			} else {
+				paths = append(paths, n.Path)
+			}
+			if len(test.skip) > 0 && n.Path == test.skip {
+				return SkipDir
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("Walk failed: %v", err)
+		}
+		if !reflect.DeepEqual(paths, test.paths) {
+			t.Errorf("Walk: got %v, expected %v", paths, test.paths)
+		}
+	}
+}
+
+func TestWalkerError(t *testing.T) {
+	st := storage.NewMemory()
+	head := makeTree(t, st)
+
+	failure := errors.New("failure")
+
+	w := NewWalker(st)
+	w.Memoize = true
+	err := w.WalkSnapshots(head, func(n *Node) error {
+		if n.Seen && n.Path == "lib" {
+			return failure
+		}
+		return nil
+	})
+	if err != failure {
+		t.Errorf("Walk: got %v, expected %v", err, failure)
+	}
+}
@@ -64,6 +64,17 @@ func (fs *Filesystem) Get(namespace, key string, flags Flags) ([]byte, error) {
 	return ioutil.ReadFile(path)
 }
 
+// Size implements Reader.Size.
+func (fs *Filesystem) Size(namespace, key string) (int64, error) {
+	path := fmt.Sprintf("%s/%s/%s", fs.root, namespace, key)
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		return 0, err
+	}
+	return fi.Size(), nil
+}
+
 // GetAll implements Reader.GetAll.
 func (fs *Filesystem) GetAll(namespace string) (map[string][]byte, error) {
 	dir := fmt.Sprintf("%s/%s", fs.root, namespace)
@@ -89,6 +100,25 @@ func (fs *Filesystem) GetAll(namespace string) (map[string][]byte, error) {
 	return kv, nil
 }
 
+// Namespaces implements NamespaceLister.Namespaces.
+func (fs *Filesystem) Namespaces(namespace string) ([]string, error) {
+	dir := fs.root
+	if len(namespace) > 0 {
+		dir = fmt.Sprintf("%s/%s", fs.root, namespace)
+	}
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var result []string
+	for _, fi := range files {
+		if fi.IsDir() {
+			result = append(result, fi.Name())
+		}
+	}
+	return result, nil
+}
+
 // Set implements Writer.Set.
 func (fs *Filesystem) Set(namespace, key string, value []byte) error {
 	dir := fmt.Sprintf("%s/%s", fs.root, namespace)
@@ -100,3 +130,42 @@ func (fs *Filesystem) Set(namespace, key string, value []byte) error {
 	path := fmt.Sprintf("%s/%s", dir, key)
 	return ioutil.WriteFile(path, value, 0644)
 }
+
+// Create implements Writer.Create. The data is written to a
+// temporary file that is linked to the key so the key is created
+// only with its complete data.
+func (fs *Filesystem) Create(namespace, key string, value []byte) error {
+	dir := fmt.Sprintf("%s/%s", fs.root, namespace)
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return err
+	}
+
+	f, err := ioutil.TempFile(dir, ".tmp-")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	defer os.Remove(tmp)
+
+	_, err = f.Write(value)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp, 0644); err != nil {
+		return err
+	}
+	err = os.Link(tmp, fmt.Sprintf("%s/%s", dir, key))
+	if os.IsExist(err) {
+		return ErrExists
+	}
+	return err
+}
+
+// Delete implements Writer.Delete.
+func (fs *Filesystem) Delete(namespace, key string) error {
+	return os.Remove(fmt.Sprintf("%s/%s/%s", fs.root, namespace, key))
+}
@@ -17,6 +17,8 @@ import (
 type HTTP struct {
 	root   string
 	client *http.Client
+	// Token is an optional bearer token for the requests.
+	Token string
 }
 
 // NewHTTP creates a new HTTP persistence storage accessor.
@@ -35,6 +37,7 @@ func (h *HTTP) Exists(namespace, key string) (bool, error) {
 	}
 	// XXX
 	req.Header.Add("js.fetch:mode", "no-cors")
+	h.authorize(req)
 	resp, err := h.client.Do(req)
 	if err != nil {
 		return false, err
@@ -52,6 +55,7 @@ func (h *HTTP) Get(namespace, key string, flags Flags) ([]byte, error) {
 	if (flags & NoCache) != 0 {
 		req.Header.Add("Cache-Control", "no-cache")
 	}
+	h.authorize(req)
 	resp, err := h.client.Do(req)
 	if err != nil {
 		return nil, err
@@ -60,6 +64,28 @@ func (h *HTTP) Get(namespace, key string, flags Flags) ([]byte, error) {
 	return ioutil.ReadAll(resp.Body)
 }
 
+// Size implements Reader.Size.
+func (h *HTTP) Size(namespace, key string) (int64, error) {
+	req, err := http.NewRequest("HEAD", h.makeURL(namespace, key), nil)
+	if err != nil {
+		return 0, err
+	}
+	h.authorize(req)
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return 0, fmt.Errorf("HEAD %s/%s: %s", namespace, key, resp.Status)
+	}
+	if resp.ContentLength < 0 {
+		return 0, fmt.Errorf("HEAD %s/%s: unknown content length",
+			namespace, key)
+	}
+	return resp.ContentLength, nil
+}
+
 // GetAll implements Reader.GetAll.
 func (h *HTTP) GetAll(namespace string) (map[string][]byte, error) {
 	return nil, errors.New("GetAll not supported for HTTP")
@@ -70,6 +96,22 @@ func (h *HTTP) Set(namespace, key string, data []byte) error {
 	return errors.New("Set not supported for HTTP")
 }
 
+// Create implements Writer.Create.
+func (h *HTTP) Create(namespace, key string, data []byte) error {
+	return errors.New("Create not supported for HTTP")
+}
+
+// Delete implements Writer.Delete.
+func (h *HTTP) Delete(namespace, key string) error {
+	return errors.New("Delete not supported for HTTP")
+}
+
+func (h *HTTP) authorize(req *http.Request) {
+	if len(h.Token) > 0 {
+		req.Header.Add("Authorization", "Bearer "+h.Token)
+	}
+}
+
 func (h *HTTP) makeURL(namespace, key string) string {
 	return fmt.Sprintf("%s/%s/%s", h.root, namespace, key)
 }
@@ -23,6 +23,18 @@ type Reader interface {
 	// Get gets the data of the specified key in the namespace.
 	Get(namespace, key string, flags Flags) ([]byte, error)
 
+	// Size returns the data size of the specified key in the
+	// namespace.
+	Size(namespace, key string) (int64, error)
+
 	// GetAll returns all keys and their values from the namespae.
 	GetAll(namespace string) (map[string][]byte, error)
 }
+
+// NamespaceLister is implemented by the persistence storages that can
+// list their namespaces.
+type NamespaceLister interface {
+	// Namespaces returns the child namespaces of the namespace. The
+	// empty namespace is the storage root.
+	Namespaces(namespace string) ([]string, error)
+}
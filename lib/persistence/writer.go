@@ -6,8 +6,23 @@
 
 package persistence
 
+import (
+	"errors"
+)
+
+// ErrExists is returned when creating a key that already exists.
+var ErrExists = errors.New("key already exists")
+
 // Writer defines persistence writer interface.
 type Writer interface {
 	// Set sets the data to the specified key in the namespace.
 	Set(namespace, key string, data []byte) error
+
+	// Create sets the data to the specified key in the namespace if
+	// the key does not exist. If the key exists, Create returns
+	// ErrExists.
+	Create(namespace, key string, data []byte) error
+
+	// Delete deletes the specified key from the namespace.
+	Delete(namespace, key string) error
 }
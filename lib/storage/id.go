@@ -10,6 +10,7 @@ import (
 	"bytes"
 	"encoding/hex"
 	"fmt"
+	"strings"
 )
 
 var (
@@ -38,6 +39,12 @@ func IDFromString(input string) (id ID, err error) {
 	return NewID(data), nil
 }
 
+// HasPrefix tests if the ID's hex representation starts with the
+// hex string prefix.
+func (id ID) HasPrefix(prefix string) bool {
+	return strings.HasPrefix(id.ToFullString(), strings.ToLower(prefix))
+}
+
 // Undefined tests if the ID is undefined.
 func (id ID) Undefined() bool {
 	return len(id.Data) == 0
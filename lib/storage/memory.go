@@ -0,0 +1,49 @@
+//
+// Copyright (c) 2026 Markku Rossi
+//
+// All rights reserved.
+//
+
+package storage
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"sync"
+)
+
+// Memory implements an in-memory storage. The storage is safe for
+// concurrent use.
+type Memory struct {
+	m       sync.Mutex
+	objects map[string][]byte
+}
+
+// NewMemory creates a new in-memory storage.
+func NewMemory() *Memory {
+	return &Memory{
+		objects: make(map[string][]byte),
+	}
+}
+
+func (m *Memory) Write(data []byte) (ID, error) {
+	sum := sha256.Sum256(data)
+	id := NewID(sum[:])
+
+	m.m.Lock()
+	m.objects[string(id.Data)] = append([]byte(nil), data...)
+	m.m.Unlock()
+
+	return id, nil
+}
+
+func (m *Memory) Read(id ID) ([]byte, error) {
+	m.m.Lock()
+	defer m.m.Unlock()
+
+	data, ok := m.objects[string(id.Data)]
+	if !ok {
+		return nil, fmt.Errorf("object %s not found", id)
+	}
+	return data, nil
+}
@@ -10,6 +10,7 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"io"
 
 	"github.com/markkurossi/backup/lib/encoding"
 	"github.com/markkurossi/backup/lib/storage"
@@ -36,6 +37,12 @@ type Element interface {
 	File() File
 }
 
+// extensible is implemented by elements whose later versions append
+// fields after the version 1 fields.
+type extensible interface {
+	unmarshalExtensions(in io.Reader) error
+}
+
 // DeserializeID deserializes an element id from the storage.
 func DeserializeID(id storage.ID, st storage.Accessor) (Element, error) {
 	if id.Undefined() {
@@ -69,14 +76,24 @@ func Deserialize(data []byte, st storage.Accessor) (Element, error) {
 	case TypeSnapshot:
 		element = new(Snapshot)
 
+	case TypeSnapshotIndex:
+		element = new(SnapshotIndex)
+
 	default:
 		return nil, fmt.Errorf("unsupported tree element type %s", elementType)
 	}
 
-	err := encoding.Unmarshal(bytes.NewReader(data), element)
+	in := bytes.NewReader(data)
+	err := encoding.Unmarshal(in, element)
 	if err != nil {
 		return nil, err
 	}
+	ext, ok := element.(extensible)
+	if ok {
+		if err := ext.unmarshalExtensions(in); err != nil {
+			return nil, err
+		}
+	}
 
 	element.SetStorage(st)
 
@@ -14,4 +14,12 @@ import (
 type File interface {
 	Size() int64
 	Reader() io.Reader
+	RandomReader() RandomReader
+}
+
+// RandomReader implements random access to file content.
+type RandomReader interface {
+	io.Reader
+	io.ReaderAt
+	io.Seeker
 }
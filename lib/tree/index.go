@@ -0,0 +1,87 @@
+//
+// Copyright (c) 2026 Markku Rossi
+//
+// All rights reserved.
+//
+
+package tree
+
+import (
+	"sort"
+
+	"github.com/markkurossi/backup/lib/encoding"
+	"github.com/markkurossi/backup/lib/storage"
+)
+
+// SnapshotIndex lists the snapshots of a zone. The snapshots are
+// ordered from the newest to the oldest.
+type SnapshotIndex struct {
+	ElementHeader
+	Snapshots []SnapshotIndexEntry
+}
+
+// SnapshotIndexEntry describes an indexed snapshot.
+type SnapshotIndexEntry struct {
+	ID        storage.ID
+	Timestamp int64
+	Tags      []string
+}
+
+// Add adds the snapshot id to the index. If the index already
+// contains the snapshot, its entry is updated.
+func (idx *SnapshotIndex) Add(id storage.ID, s *Snapshot) {
+	idx.Remove(id)
+	entry := SnapshotIndexEntry{
+		ID:        id,
+		Timestamp: s.Timestamp,
+		Tags:      s.Meta.Tags,
+	}
+	i := sort.Search(len(idx.Snapshots), func(i int) bool {
+		return idx.Snapshots[i].Timestamp < s.Timestamp
+	})
+	idx.Snapshots = append(idx.Snapshots, SnapshotIndexEntry{})
+	copy(idx.Snapshots[i+1:], idx.Snapshots[i:])
+	idx.Snapshots[i] = entry
+}
+
+// Remove removes the snapshot id from the index. The function
+// returns true if the snapshot was removed.
+func (idx *SnapshotIndex) Remove(id storage.ID) bool {
+	for i, entry := range idx.Snapshots {
+		if entry.ID.Equal(id) {
+			idx.Snapshots = append(idx.Snapshots[:i], idx.Snapshots[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Serialize implements Element.Serialize.
+func (idx *SnapshotIndex) Serialize() ([]byte, error) {
+	return encoding.Marshal(idx)
+}
+
+// IsDir implements Element.IsDir.
+func (idx *SnapshotIndex) IsDir() bool {
+	return false
+}
+
+// Directory implements Element.Directory.
+func (idx *SnapshotIndex) Directory() *Directory {
+	panic("SnapshotIndex can't be converted to Directory")
+}
+
+// File implements Element.File.
+func (idx *SnapshotIndex) File() File {
+	panic("SnapshotIndex can't be converted to File")
+}
+
+// NewSnapshotIndex creates a new snapshot index object.
+func NewSnapshotIndex() *SnapshotIndex {
+	return &SnapshotIndex{
+		ElementHeader: ElementHeader{
+			Type:    TypeSnapshotIndex,
+			Version: 1,
+		},
+	}
+}
@@ -0,0 +1,54 @@
+//
+// Copyright (c) 2026 Markku Rossi
+//
+// All rights reserved.
+//
+
+package tree
+
+import (
+	"testing"
+
+	"github.com/markkurossi/backup/lib/storage"
+)
+
+func TestSnapshotIndex(t *testing.T) {
+	idx := NewSnapshotIndex()
+
+	for i, ts := range []int64{20, 10, 30, 20} {
+		s := NewSnapshot()
+		s.Timestamp = ts
+		s.Meta.Tags = []string{"tag"}
+		idx.Add(storage.NewID([]byte{byte(i)}), s)
+	}
+	if !idx.Remove(storage.NewID([]byte{1})) {
+		t.Errorf("Remove failed")
+	}
+	if idx.Remove(storage.NewID([]byte{1})) {
+		t.Errorf("Remove of a removed snapshot succeeded")
+	}
+
+	data, err := idx.Serialize()
+	if err != nil {
+		t.Fatalf("Serialize failed: %v", err)
+	}
+	el, err := Deserialize(data, nil)
+	if err != nil {
+		t.Fatalf("Deserialize failed: %v", err)
+	}
+	idx2, ok := el.(*SnapshotIndex)
+	if !ok {
+		t.Fatalf("Deserialize returned %T", el)
+	}
+	var timestamps []int64
+	for _, e := range idx2.Snapshots {
+		timestamps = append(timestamps, e.Timestamp)
+		if len(e.Tags) != 1 || e.Tags[0] != "tag" {
+			t.Errorf("unexpected tags: %v", e.Tags)
+		}
+	}
+	if len(timestamps) != 3 || timestamps[0] != 30 || timestamps[1] != 20 ||
+		timestamps[2] != 20 {
+		t.Errorf("unexpected order: %v", timestamps)
+	}
+}
@@ -0,0 +1,169 @@
+//
+// Copyright (c) 2026 Markku Rossi
+//
+// All rights reserved.
+//
+
+package tree
+
+import (
+	"container/list"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+
+	"github.com/markkurossi/backup/lib/storage"
+)
+
+// ChunkCacheSize defines the number of decrypted chunks that the
+// ChunkedFile random readers cache.
+const ChunkCacheSize = 4
+
+// RandomReader returns a random access reader for the file
+// content. The reader locates chunks with a binary search over the
+// cumulative chunk offsets and caches the most recently used
+// chunks. The reader's ReadAt is safe for concurrent use.
+func (c *ChunkedFile) RandomReader() RandomReader {
+	offsets := make([]int64, len(c.Chunks)+1)
+	for idx, chunk := range c.Chunks {
+		offsets[idx+1] = offsets[idx] + chunk.Size
+	}
+	return &chunkRandomReader{
+		st:      c.st,
+		chunks:  c.Chunks,
+		offsets: offsets,
+		cache:   newChunkCache(ChunkCacheSize),
+	}
+}
+
+type chunkRandomReader struct {
+	st      storage.Accessor
+	chunks  []Chunk
+	offsets []int64
+	cache   *chunkCache
+	pos     int64
+}
+
+func (r *chunkRandomReader) size() int64 {
+	return r.offsets[len(r.offsets)-1]
+}
+
+func (r *chunkRandomReader) Read(p []byte) (int, error) {
+	n, err := r.ReadAt(p, r.pos)
+	r.pos += int64(n)
+	if n > 0 && err == io.EOF {
+		err = nil
+	}
+	return n, err
+}
+
+func (r *chunkRandomReader) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, errors.New("negative offset")
+	}
+	var n int
+	for n < len(p) {
+		if off >= r.size() {
+			return n, io.EOF
+		}
+		idx := sort.Search(len(r.chunks), func(i int) bool {
+			return r.offsets[i+1] > off
+		})
+		data, err := r.chunk(idx)
+		if err != nil {
+			return n, err
+		}
+		read := copy(p[n:], data[off-r.offsets[idx]:])
+		n += read
+		off += int64(read)
+	}
+	return n, nil
+}
+
+func (r *chunkRandomReader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += r.pos
+	case io.SeekEnd:
+		offset += r.size()
+	default:
+		return 0, errors.New("invalid whence")
+	}
+	if offset < 0 {
+		return 0, errors.New("negative position")
+	}
+	r.pos = offset
+	return offset, nil
+}
+
+func (r *chunkRandomReader) chunk(idx int) ([]byte, error) {
+	data, ok := r.cache.get(idx)
+	if ok {
+		return data, nil
+	}
+	data, err := r.st.Read(r.chunks[idx].Content)
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) != r.chunks[idx].Size {
+		return nil, fmt.Errorf("chunk %d size mismatch: got %d, expected %d",
+			idx, len(data), r.chunks[idx].Size)
+	}
+	r.cache.add(idx, data)
+	return data, nil
+}
+
+// chunkCache implements an LRU cache of chunk data.
+type chunkCache struct {
+	m       sync.Mutex
+	size    int
+	lru     *list.List
+	entries map[int]*list.Element
+}
+
+type chunkCacheEntry struct {
+	idx  int
+	data []byte
+}
+
+func newChunkCache(size int) *chunkCache {
+	return &chunkCache{
+		size:    size,
+		lru:     list.New(),
+		entries: make(map[int]*list.Element),
+	}
+}
+
+func (c *chunkCache) get(idx int) ([]byte, bool) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	e, ok := c.entries[idx]
+	if !ok {
+		return nil, false
+	}
+	c.lru.MoveToFront(e)
+	return e.Value.(*chunkCacheEntry).data, true
+}
+
+func (c *chunkCache) add(idx int, data []byte) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	if e, ok := c.entries[idx]; ok {
+		c.lru.MoveToFront(e)
+		return
+	}
+	c.entries[idx] = c.lru.PushFront(&chunkCacheEntry{
+		idx:  idx,
+		data: data,
+	})
+	for c.lru.Len() > c.size {
+		e := c.lru.Back()
+		c.lru.Remove(e)
+		delete(c.entries, e.Value.(*chunkCacheEntry).idx)
+	}
+}
@@ -0,0 +1,92 @@
+//
+// Copyright (c) 2026 Markku Rossi
+//
+// All rights reserved.
+//
+
+package tree
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/markkurossi/backup/lib/storage"
+)
+
+type countingStorage struct {
+	storage.Accessor
+	reads int
+}
+
+func (st *countingStorage) Read(id storage.ID) ([]byte, error) {
+	st.reads++
+	return st.Accessor.Read(id)
+}
+
+func TestRandomReader(t *testing.T) {
+	st := &countingStorage{
+		Accessor: storage.NewMemory(),
+	}
+	content := make([]byte, 100)
+	for i := range content {
+		content[i] = byte(i)
+	}
+	id, _, err := StoreFile(bytes.NewReader(content), 16, st)
+	if err != nil {
+		t.Fatalf("StoreFile failed: %v", err)
+	}
+	el, err := DeserializeID(id, st)
+	if err != nil {
+		t.Fatalf("DeserializeID failed: %v", err)
+	}
+	r := el.File().RandomReader()
+
+	for _, test := range []struct {
+		off int64
+		len int
+		n   int
+		err error
+	}{
+		{0, 10, 10, nil},
+		{10, 10, 10, nil},
+		{15, 2, 2, nil},
+		{90, 10, 10, nil},
+		{90, 20, 10, io.EOF},
+		{0, 100, 100, nil},
+		{100, 1, 0, io.EOF},
+	} {
+		buf := make([]byte, test.len)
+		n, err := r.ReadAt(buf, test.off)
+		if n != test.n || err != test.err {
+			t.Errorf("ReadAt(%d, %d): got %d %v, expected %d %v",
+				test.len, test.off, n, err, test.n, test.err)
+		}
+		if !bytes.Equal(buf[:n], content[test.off:test.off+int64(n)]) {
+			t.Errorf("ReadAt(%d, %d): content mismatch", test.len, test.off)
+		}
+	}
+
+	pos, err := r.Seek(-20, io.SeekEnd)
+	if err != nil || pos != 80 {
+		t.Fatalf("Seek: got %d %v", pos, err)
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("ReadAll failed: %v", err)
+	}
+	if !bytes.Equal(data, content[80:]) {
+		t.Errorf("Read after Seek: content mismatch")
+	}
+
+	st.reads = 0
+	buf := make([]byte, 4)
+	for i := 0; i < 10; i++ {
+		if _, err := r.ReadAt(buf, 96); err != nil {
+			t.Fatalf("ReadAt failed: %v", err)
+		}
+	}
+	if st.reads != 0 {
+		t.Errorf("cached chunk read %d times from storage", st.reads)
+	}
+}
@@ -7,6 +7,7 @@
 package tree
 
 import (
+	"bytes"
 	"io"
 
 	"github.com/markkurossi/backup/lib/encoding"
@@ -50,6 +51,11 @@ func (f *SimpleFile) Reader() io.Reader {
 	}
 }
 
+// RandomReader implements File.RandomReader.
+func (f *SimpleFile) RandomReader() RandomReader {
+	return bytes.NewReader(f.Content)
+}
+
 // SimpleReader implements io.Reader for simple file.
 type SimpleReader struct {
 	data []byte
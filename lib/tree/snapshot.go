@@ -8,18 +8,81 @@ package tree
 
 import (
 	"fmt"
+	"io"
 
 	"github.com/markkurossi/backup/lib/encoding"
 	"github.com/markkurossi/backup/lib/storage"
 )
 
-// Snapshot implements snapshot objects.
+// SnapshotVersion defines the current snapshot object version.
+const SnapshotVersion Version = 4
+
+// Snapshot implements snapshot objects. The version 1 snapshots
+// contain only the header fields. The version 2 snapshots add the
+// snapshot metadata after the header fields, the version 3 snapshots
+// add the snapshot statistics after the metadata, and the version 4
+// snapshots add the snapshot signature after the statistics.
 type Snapshot struct {
 	ElementHeader
 	Timestamp int64
 	Size      FileSize
 	Root      storage.ID
 	Parent    storage.ID
+	Meta      SnapshotMeta  `backup:"-"`
+	Stats     SnapshotStats `backup:"-"`
+	Signature Signature     `backup:"-"`
+}
+
+// SnapshotMeta defines the snapshot metadata.
+type SnapshotMeta struct {
+	Hostname    string
+	Username    string
+	Paths       []string
+	ToolVersion string
+	Tags        []string
+	Message     string
+}
+
+// SnapshotStats defines the snapshot statistics.
+type SnapshotStats struct {
+	// Files is the number of files in the snapshot.
+	Files int64
+	// Dirs is the number of directories in the snapshot.
+	Dirs int64
+	// LogicalSize is the total size of the files in the snapshot.
+	LogicalSize FileSize
+	// NewBytes is the number of bytes that were added to the zone
+	// by this snapshot.
+	NewBytes FileSize
+	// NewObjects is the number of objects that were added to the
+	// zone by this snapshot.
+	NewObjects int64
+	// DedupBytes is the number of bytes that were already stored in
+	// the zone.
+	DedupBytes FileSize
+	// CompressedBytes is the number of bytes saved by compression.
+	CompressedBytes FileSize
+	// Elapsed is the snapshot creation time in nanoseconds.
+	Elapsed int64
+}
+
+// Signature defines the snapshot signature. The Signer is the key ID
+// of the signing identity key and the Value is the signature over
+// the snapshot data without the signature value. The unsigned
+// snapshots have an empty Signer.
+type Signature struct {
+	Signer string
+	Value  []byte
+}
+
+// HasTag tests if the snapshot has the tag.
+func (s *Snapshot) HasTag(tag string) bool {
+	for _, t := range s.Meta.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
 }
 
 func (s *Snapshot) String() string {
@@ -28,7 +91,66 @@ func (s *Snapshot) String() string {
 
 // Serialize implements Element.Serialize.
 func (s *Snapshot) Serialize() ([]byte, error) {
-	return encoding.Marshal(s)
+	data, err := encoding.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	if s.Version < 2 {
+		return data, nil
+	}
+	meta, err := encoding.Marshal(&s.Meta)
+	if err != nil {
+		return nil, err
+	}
+	data = append(data, meta...)
+	if s.Version < 3 {
+		return data, nil
+	}
+	stats, err := encoding.Marshal(&s.Stats)
+	if err != nil {
+		return nil, err
+	}
+	data = append(data, stats...)
+	if s.Version < 4 {
+		return data, nil
+	}
+	sig, err := encoding.Marshal(&s.Signature)
+	if err != nil {
+		return nil, err
+	}
+	return append(data, sig...), nil
+}
+
+// SignedData returns the snapshot data that the snapshot signature
+// covers. The signed data is the serialized snapshot without the
+// signature value.
+func (s *Snapshot) SignedData() ([]byte, error) {
+	if s.Version < 4 {
+		return nil, fmt.Errorf("snapshot version %d can't be signed",
+			s.Version)
+	}
+	unsigned := *s
+	unsigned.Signature.Value = nil
+	return unsigned.Serialize()
+}
+
+func (s *Snapshot) unmarshalExtensions(in io.Reader) error {
+	if s.Version < 2 {
+		return nil
+	}
+	if err := encoding.Unmarshal(in, &s.Meta); err != nil {
+		return err
+	}
+	if s.Version < 3 {
+		return nil
+	}
+	if err := encoding.Unmarshal(in, &s.Stats); err != nil {
+		return err
+	}
+	if s.Version < 4 {
+		return nil
+	}
+	return encoding.Unmarshal(in, &s.Signature)
 }
 
 // IsDir implements Element.IsDir.
@@ -51,7 +173,7 @@ func NewSnapshot() *Snapshot {
 	return &Snapshot{
 		ElementHeader: ElementHeader{
 			Type:    TypeSnapshot,
-			Version: 1,
+			Version: SnapshotVersion,
 		},
 	}
 }
@@ -0,0 +1,117 @@
+//
+// Copyright (c) 2026 Markku Rossi
+//
+// All rights reserved.
+//
+
+package tree
+
+import (
+	"testing"
+
+	"github.com/markkurossi/backup/lib/storage"
+)
+
+func TestSnapshotV1(t *testing.T) {
+	s := NewSnapshot()
+	s.Version = 1
+	s.Timestamp = 42
+	s.Root = storage.NewID([]byte{1, 2, 3})
+	s.Meta.Hostname = "ignored"
+
+	data, err := s.Serialize()
+	if err != nil {
+		t.Fatalf("Failed to serialize snapshot: %v", err)
+	}
+	el, err := Deserialize(data, nil)
+	if err != nil {
+		t.Fatalf("Failed to deserialize version 1 snapshot: %v", err)
+	}
+	s2 := el.(*Snapshot)
+	if s2.Timestamp != 42 || !s2.Root.Equal(s.Root) {
+		t.Errorf("Snapshot mismatch: %v", s2)
+	}
+	if len(s2.Meta.Hostname) != 0 {
+		t.Errorf("Version 1 snapshot has metadata")
+	}
+}
+
+func TestSnapshotMeta(t *testing.T) {
+	s := NewSnapshot()
+	s.Timestamp = 42
+	s.Root = storage.NewID([]byte{1, 2, 3})
+	s.Meta.Hostname = "host"
+	s.Meta.Paths = []string{"/home/user"}
+	s.Meta.Tags = []string{"daily", "pre-upgrade"}
+	s.Meta.Message = "Hello, world!"
+	s.Stats.Files = 7
+	s.Stats.LogicalSize = 1234567
+	s.Signature.Signer = "sha256:1234"
+	s.Signature.Value = []byte("signature")
+
+	data, err := s.Serialize()
+	if err != nil {
+		t.Fatalf("Failed to serialize snapshot: %v", err)
+	}
+	el, err := Deserialize(data, nil)
+	if err != nil {
+		t.Fatalf("Failed to deserialize snapshot: %v", err)
+	}
+	s2 := el.(*Snapshot)
+	if s2.Meta.Hostname != "host" || s2.Meta.Message != s.Meta.Message {
+		t.Errorf("Snapshot metadata mismatch: %v", s2.Meta)
+	}
+	if !s2.HasTag("pre-upgrade") || s2.HasTag("weekly") {
+		t.Errorf("Snapshot tag mismatch: %v", s2.Meta.Tags)
+	}
+	if s2.Stats.Files != 7 || s2.Stats.LogicalSize != 1234567 {
+		t.Errorf("Snapshot stats mismatch: %v", s2.Stats)
+	}
+	if s2.Signature.Signer != s.Signature.Signer ||
+		string(s2.Signature.Value) != "signature" {
+		t.Errorf("Snapshot signature mismatch: %v", s2.Signature)
+	}
+	signed, err := s2.SignedData()
+	if err != nil {
+		t.Fatalf("SignedData failed: %v", err)
+	}
+	s.Signature.Value = nil
+	unsigned, err := s.Serialize()
+	if err != nil {
+		t.Fatalf("Failed to serialize snapshot: %v", err)
+	}
+	if string(signed) != string(unsigned) {
+		t.Errorf("Signed data includes the signature value")
+	}
+
+	s.Version = 2
+	data, err = s.Serialize()
+	if err != nil {
+		t.Fatalf("Failed to serialize snapshot: %v", err)
+	}
+	el, err = Deserialize(data, nil)
+	if err != nil {
+		t.Fatalf("Failed to deserialize version 2 snapshot: %v", err)
+	}
+	s2 = el.(*Snapshot)
+	if s2.Meta.Hostname != "host" || s2.Stats.Files != 0 {
+		t.Errorf("Version 2 snapshot mismatch: %v", s2)
+	}
+}
+
+func TestFileSize(t *testing.T) {
+	for _, test := range []struct {
+		size FileSize
+		str  string
+	}{
+		{512, "512 B"},
+		{1536, "1.5 kB"},
+		{5*1024*1024 + 512*1024, "5.5 MB"},
+		{3 * 1024 * 1024 * 1024, "3.0 GB"},
+	} {
+		if test.size.String() != test.str {
+			t.Errorf("FileSize(%d): got %s, expected %s", test.size,
+				test.size.String(), test.str)
+		}
+	}
+}
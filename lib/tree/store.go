@@ -0,0 +1,64 @@
+//
+// Copyright (c) 2026 Markku Rossi
+//
+// All rights reserved.
+//
+
+package tree
+
+import (
+	"io"
+
+	"github.com/markkurossi/backup/lib/storage"
+)
+
+// StoreFile reads the file content from the reader r and stores it
+// into the storage writer w. Content shorter than chunkSize is
+// stored as a SimpleFile and longer content as a ChunkedFile of
+// chunkSize chunks. The reader is read until EOF so the content size
+// does not have to be known in advance. The function returns the
+// file element ID and the content size.
+func StoreFile(r io.Reader, chunkSize int64, w storage.Writer) (
+	storage.ID, int64, error) {
+
+	var id storage.ID
+
+	buf := make([]byte, chunkSize)
+	n, err := io.ReadFull(r, buf)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		data, err := NewSimpleFile(buf[:n]).Serialize()
+		if err != nil {
+			return id, 0, err
+		}
+		id, err = w.Write(data)
+		return id, int64(n), err
+	}
+	if err != nil {
+		return id, 0, err
+	}
+
+	cf := NewChunkedFile(0)
+	for {
+		chunk, err := w.Write(buf[:n])
+		if err != nil {
+			return id, 0, err
+		}
+		cf.Add(int64(n), chunk)
+		cf.ContentSize += int64(n)
+
+		n, err = io.ReadFull(r, buf)
+		if err == io.EOF {
+			break
+		}
+		if err != nil && err != io.ErrUnexpectedEOF {
+			return id, 0, err
+		}
+	}
+
+	data, err := cf.Serialize()
+	if err != nil {
+		return id, 0, err
+	}
+	id, err = w.Write(data)
+	return id, cf.ContentSize, err
+}
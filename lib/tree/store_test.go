@@ -0,0 +1,51 @@
+//
+// Copyright (c) 2026 Markku Rossi
+//
+// All rights reserved.
+//
+
+package tree
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/markkurossi/backup/lib/storage"
+)
+
+func TestStoreFile(t *testing.T) {
+	st := storage.NewMemory()
+
+	for _, size := range []int{0, 1, 15, 16, 17, 32, 100} {
+		content := make([]byte, size)
+		for i := range content {
+			content[i] = byte(i)
+		}
+		id, n, err := StoreFile(bytes.NewReader(content), 16, st)
+		if err != nil {
+			t.Fatalf("StoreFile failed: %v", err)
+		}
+		if n != int64(size) {
+			t.Errorf("size %d: StoreFile returned size %d", size, n)
+		}
+		el, err := DeserializeID(id, st)
+		if err != nil {
+			t.Fatalf("DeserializeID failed: %v", err)
+		}
+		_, chunked := el.(*ChunkedFile)
+		if chunked != (size >= 16) {
+			t.Errorf("size %d: unexpected element %T", size, el)
+		}
+		if el.File().Size() != int64(size) {
+			t.Errorf("size %d: element size %d", size, el.File().Size())
+		}
+		data, err := io.ReadAll(el.File().Reader())
+		if err != nil {
+			t.Fatalf("read failed: %v", err)
+		}
+		if !bytes.Equal(data, content) {
+			t.Errorf("size %d: content mismatch", size)
+		}
+	}
+}
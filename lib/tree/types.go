@@ -14,10 +14,11 @@ import (
 type Type uint8
 
 var typeNames = map[Type]string{
-	TypeSimpleFile:  "simple-file",
-	TypeChunkedFile: "chunked-file",
-	TypeDirectory:   "directory",
-	TypeSnapshot:    "snapshot",
+	TypeSimpleFile:    "simple-file",
+	TypeChunkedFile:   "chunked-file",
+	TypeDirectory:     "directory",
+	TypeSnapshot:      "snapshot",
+	TypeSnapshotIndex: "snapshot-index",
 }
 
 func (t Type) String() string {
@@ -34,6 +35,7 @@ const (
 	TypeChunkedFile
 	TypeDirectory
 	TypeSnapshot
+	TypeSnapshotIndex
 )
 
 // Version defines object version.
@@ -42,12 +44,17 @@ type Version uint8
 // FileSize defines file size in bytes.
 type FileSize int64
 
+var fileSizeUnits = []string{"kB", "MB", "GB", "TB", "PB"}
+
 func (size FileSize) String() string {
-	if size > 1024*1024 {
-		return fmt.Sprintf("%d MB", size/(1024*1024))
-	} else if size > 1024 {
-		return fmt.Sprintf("%d kB", size/1024)
-	} else {
+	if size < 1024 {
 		return fmt.Sprintf("%d B", size)
 	}
+	value := float64(size) / 1024
+	unit := 0
+	for value >= 1024 && unit+1 < len(fileSizeUnits) {
+		value /= 1024
+		unit++
+	}
+	return fmt.Sprintf("%.1f %s", value, fileSizeUnits[unit])
 }